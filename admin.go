@@ -0,0 +1,524 @@
+package xbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/message"
+)
+
+// EventRecord 最近事件记录，用于管理后台展示
+type EventRecord struct {
+	Time     time.Time `json:"time"`
+	SelfID   int64     `json:"self_id"`
+	PostType string    `json:"post_type"`
+	Type     string    `json:"type"`
+	UserID   int64     `json:"user_id,omitempty"`
+	GroupID  int64     `json:"group_id,omitempty"`
+	Summary  string    `json:"summary,omitempty"`
+}
+
+// newEventRecord 根据事件生成记录
+func newEventRecord(evt event.Event) EventRecord {
+	record := EventRecord{
+		Time:     time.Now(),
+		SelfID:   evt.GetSelfID(),
+		PostType: evt.GetPostType(),
+		Type:     strings.TrimPrefix(fmt.Sprintf("%T", evt), "*event."),
+		UserID:   eventInt64Field(evt, "UserID"),
+		GroupID:  eventInt64Field(evt, "GroupID"),
+	}
+
+	switch e := evt.(type) {
+	case *event.PrivateMessageEvent:
+		record.Summary = e.ParsedMessage.GetRawMessage()
+	case *event.GroupMessageEvent:
+		record.Summary = e.ParsedMessage.GetRawMessage()
+	case *event.RecalledMessageEvent:
+		record.Summary = e.Message.GetRawMessage()
+	}
+
+	return record
+}
+
+// eventInt64Field 通过反射读取事件中的整数字段，不存在时返回 0
+func eventInt64Field(evt event.Event, name string) int64 {
+	v := reflect.ValueOf(evt)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+
+	f := v.FieldByName(name)
+	if !f.IsValid() {
+		return 0
+	}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return f.Int()
+	default:
+		return 0
+	}
+}
+
+// RecentEvents 获取最近收到的事件，按时间从旧到新排列
+func (bm *BotManager) RecentEvents() []EventRecord {
+	return bm.recentEvents.Items()
+}
+
+// ========== 管理后台 ==========
+
+// AdminServer 管理后台 HTTP 服务
+// 提供机器人、驱动器、插件、最近事件的查询接口，以及手动发送消息和启用/禁用插件的接口
+type AdminServer struct {
+	manager *BotManager
+	token   string
+	server  *http.Server
+}
+
+// NewAdminServer 创建管理后台服务
+// token 为空时不做鉴权，只允许监听本机回环地址（如 127.0.0.1:8081、localhost:8081），否则返回错误
+func NewAdminServer(manager *BotManager, addr, token string) (*AdminServer, error) {
+	if token == "" && !isLoopbackAddr(addr) {
+		return nil, fmt.Errorf("管理后台监听非本机地址 %s 时必须设置访问令牌", addr)
+	}
+
+	s := &AdminServer{
+		manager: manager,
+		token:   token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.Handle("GET /healthz", manager.HealthHandler())
+	mux.Handle("GET /readyz", manager.ReadyHandler())
+	mux.HandleFunc("GET /api/health", s.auth(s.handleHealth))
+	mux.HandleFunc("GET /api/bots", s.auth(s.handleBots))
+	mux.HandleFunc("GET /api/drivers", s.auth(s.handleDrivers))
+	mux.HandleFunc("GET /api/engines", s.auth(s.handleEngines))
+	mux.HandleFunc("GET /api/events/recent", s.auth(s.handleRecentEvents))
+	mux.HandleFunc("POST /api/send", s.auth(s.handleSend))
+	mux.HandleFunc("POST /api/engines/{name}/enable", s.auth(s.handleToggleEngine(true)))
+	mux.HandleFunc("POST /api/engines/{name}/disable", s.auth(s.handleToggleEngine(false)))
+
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	return s, nil
+}
+
+// isLoopbackAddr 监听地址是否只绑定本机回环地址，未指定主机（如 ":8081"）时监听所有网卡
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Handler 获取 HTTP 处理器，便于挂载到已有的 HTTP 服务
+func (s *AdminServer) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Start 在后台启动 HTTP 服务
+func (s *AdminServer) Start() {
+	go func() {
+		logger.Info("管理后台启动", "addr", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("管理后台启动失败", "error", err)
+		}
+	}()
+}
+
+// Close 关闭 HTTP 服务
+func (s *AdminServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// auth 校验访问令牌
+// 只接受 Authorization: Bearer <token> 头部，不接受查询参数，避免令牌出现在访问日志和代理日志中
+func (s *AdminServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeJSONError(w, http.StatusUnauthorized, "未授权")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// handleBots 获取在线机器人列表
+func (s *AdminServer) handleBots(w http.ResponseWriter, r *http.Request) {
+	type botInfo struct {
+		SelfID  int64 `json:"self_id"`
+		Engines int   `json:"engines"`
+	}
+
+	bots := make([]botInfo, 0)
+	for _, bot := range s.manager.GetAllBots() {
+		bots = append(bots, botInfo{
+			SelfID:  bot.SelfID,
+			Engines: len(bot.engines),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, bots)
+}
+
+// handleHealth 获取各机器人的心跳健康状态
+func (s *AdminServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.manager.AllBotHealth())
+}
+
+// handleDrivers 获取驱动器连接状态
+func (s *AdminServer) handleDrivers(w http.ResponseWriter, r *http.Request) {
+	type driverInfo struct {
+		Index     int    `json:"index"`
+		Type      string `json:"type"`
+		URL       string `json:"url,omitempty"`
+		Connected bool   `json:"connected"`
+	}
+
+	drivers := make([]driverInfo, 0, len(s.manager.drivers))
+	for i, d := range s.manager.drivers {
+		info := driverInfo{
+			Index:     i,
+			Type:      fmt.Sprintf("%T", d),
+			Connected: d.IsConnected(),
+		}
+		if i < len(s.manager.driverConfigs) {
+			info.Type = s.manager.driverConfigs[i].Type
+			info.URL = s.manager.driverConfigs[i].URL
+		}
+		drivers = append(drivers, info)
+	}
+
+	writeJSON(w, http.StatusOK, drivers)
+}
+
+// handleEngines 获取已注册的引擎（插件）及其匹配器
+func (s *AdminServer) handleEngines(w http.ResponseWriter, r *http.Request) {
+	type matcherInfo struct {
+		ID       int64  `json:"id"`
+		Name     string `json:"name"`
+		Pattern  string `json:"pattern,omitempty"`
+		Priority int    `json:"priority"`
+		Block    bool   `json:"block"`
+		Enabled  bool   `json:"enabled"`
+	}
+	type engineInfo struct {
+		Name     string        `json:"name"`
+		Enabled  bool          `json:"enabled"`
+		Matchers []matcherInfo `json:"matchers"`
+	}
+
+	engines := make([]engineInfo, 0)
+	for _, engine := range GetEngines() {
+		info := engineInfo{
+			Name:     engine.GetName(),
+			Enabled:  engine.IsEnabled(),
+			Matchers: make([]matcherInfo, 0),
+		}
+		for _, m := range engine.Matchers() {
+			info.Matchers = append(info.Matchers, matcherInfo{
+				ID:       m.ID(),
+				Name:     m.GetName(),
+				Pattern:  m.GetPattern(),
+				Priority: m.GetPriority(),
+				Block:    m.IsBlock(),
+				Enabled:  m.IsEnabled(),
+			})
+		}
+		engines = append(engines, info)
+	}
+
+	writeJSON(w, http.StatusOK, engines)
+}
+
+// handleRecentEvents 获取最近事件，可通过 ?limit=N 限制数量，最新的在前
+func (s *AdminServer) handleRecentEvents(w http.ResponseWriter, r *http.Request) {
+	records := s.manager.RecentEvents()
+
+	// 最新的事件排在前面
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	if records == nil {
+		records = make([]EventRecord, 0)
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
+
+// sendRequest 发送消息请求
+type sendRequest struct {
+	SelfID  int64  `json:"self_id"`  // 可选，只有一个机器人时可省略
+	UserID  int64  `json:"user_id"`  // 私聊目标
+	GroupID int64  `json:"group_id"` // 群聊目标，优先于 user_id
+	Message string `json:"message"`  // 消息内容，支持 CQ 码
+}
+
+// handleSend 手动发送消息
+func (s *AdminServer) handleSend(w http.ResponseWriter, r *http.Request) {
+	var req sendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "请求格式错误: "+err.Error())
+		return
+	}
+	if req.Message == "" || (req.UserID == 0 && req.GroupID == 0) {
+		writeJSONError(w, http.StatusBadRequest, "message 以及 user_id 或 group_id 不能为空")
+		return
+	}
+
+	bot, err := s.pickBot(req.SelfID)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if bot.API == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "机器人没有可用的驱动器")
+		return
+	}
+
+	msg := message.ParseCQCode(req.Message)
+
+	var messageID int64
+	if req.GroupID != 0 {
+		resp, err := bot.API.SendGroupMsg(req.GroupID, msg)
+		if err != nil {
+			writeJSONError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		messageID = resp.Data.MessageID
+	} else {
+		resp, err := bot.API.SendPrivateMsg(req.UserID, msg)
+		if err != nil {
+			writeJSONError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		messageID = resp.Data.MessageID
+	}
+
+	logger.Info("管理后台发送消息", "selfID", bot.SelfID, "userID", req.UserID, "groupID", req.GroupID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"message_id": messageID})
+}
+
+// pickBot 选择发送消息的机器人
+func (s *AdminServer) pickBot(selfID int64) (*Bot, error) {
+	if selfID != 0 {
+		bot, ok := s.manager.GetBot(selfID)
+		if !ok {
+			return nil, fmt.Errorf("机器人 %d 不在线", selfID)
+		}
+		return bot, nil
+	}
+
+	bots := s.manager.GetAllBots()
+	switch len(bots) {
+	case 0:
+		return nil, fmt.Errorf("没有在线的机器人")
+	case 1:
+		return bots[0], nil
+	default:
+		return nil, fmt.Errorf("存在多个机器人，请指定 self_id")
+	}
+}
+
+// handleToggleEngine 启用或禁用引擎
+func (s *AdminServer) handleToggleEngine(enable bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		engine, ok := GetEngine(name)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "引擎不存在: "+name)
+			return
+		}
+
+		if enable {
+			engine.Enable()
+		} else {
+			engine.Disable()
+		}
+
+		logger.Info("管理后台切换插件状态", "engine", name, "enabled", enable)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": name, "enabled": engine.IsEnabled()})
+	}
+}
+
+// handleIndex 状态页面
+// 页面本身不包含数据，由浏览器携带令牌调用 JSON 接口渲染
+func (s *AdminServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(adminIndexHTML))
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Warn("输出 JSON 响应失败", "error", err)
+	}
+}
+
+// writeJSONError 输出 JSON 错误响应
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// adminIndexHTML 管理后台页面
+const adminIndexHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>xbot 管理后台</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { margin-top: 1.5em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; font-size: 14px; }
+th { background: #f5f5f5; }
+.ok { color: #2a2; } .bad { color: #c22; }
+input, textarea { margin: 2px 0; }
+</style>
+</head>
+<body>
+<h1>xbot 管理后台</h1>
+<p>令牌 <input id="token" type="password"> <button id="save_token">保存</button></p>
+
+<h2>机器人</h2><table id="bots"></table>
+<h2>驱动器</h2><table id="drivers"></table>
+<h2>插件</h2><table id="engines"></table>
+
+<h2>发送消息</h2>
+<p>
+self_id <input id="self_id" size="12">
+user_id <input id="user_id" size="12">
+group_id <input id="group_id" size="12"><br>
+<textarea id="message" rows="3" cols="60" placeholder="消息内容，支持 CQ 码"></textarea><br>
+<button id="send">发送</button> <span id="send_result"></span>
+</p>
+
+<h2>最近事件</h2><table id="events"></table>
+
+<script>
+// 所有数据通过 DOM API 以文本节点写入页面，不拼接 HTML，避免插件名、消息内容等注入脚本
+const $ = id => document.getElementById(id);
+$("token").value = localStorage.getItem("xbot_token") || "";
+$("save_token").addEventListener("click", () => { localStorage.setItem("xbot_token", $("token").value); refresh(); });
+$("send").addEventListener("click", send);
+
+async function api(method, path, body) {
+  const resp = await fetch(path, {
+    method,
+    headers: {"Authorization": "Bearer " + $("token").value, "Content-Type": "application/json"},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await resp.json();
+  if (!resp.ok) throw new Error(data.error || resp.statusText);
+  return data;
+}
+
+// el 创建元素，children 为节点或文本
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  Object.assign(node, attrs || {});
+  node.append(...children.map(c => c instanceof Node ? c : String(c ?? "")));
+  return node;
+}
+
+function status(ok, yes, no) {
+  return el("span", {className: ok ? "ok" : "bad"}, ok ? yes : no);
+}
+
+function table(id, head, rows) {
+  $(id).replaceChildren(
+    el("tr", null, ...head.map(h => el("th", null, h))),
+    ...rows.map(r => el("tr", null, ...r.map(c => el("td", null, ...[].concat(c))))));
+}
+
+function toggleButton(engine) {
+  const button = el("button", null, engine.enabled ? "禁用" : "启用");
+  button.addEventListener("click", () => toggle(engine.name, !engine.enabled));
+  return button;
+}
+
+async function refresh() {
+  try {
+    const bots = await api("GET", "/api/bots");
+    table("bots", ["self_id", "引擎数"], bots.map(b => [b.self_id, b.engines]));
+
+    const drivers = await api("GET", "/api/drivers");
+    table("drivers", ["#", "类型", "地址", "状态"], drivers.map(d => [d.index, d.type, d.url || "",
+      status(d.connected, "已连接", "未连接")]));
+
+    const engines = await api("GET", "/api/engines");
+    table("engines", ["名称", "状态", "匹配器", "操作"], engines.map(e => [e.name,
+      status(e.enabled, "启用", "禁用"),
+      e.matchers.flatMap((m, i) => [i > 0 ? el("br") : "",
+        m.name + (m.pattern ? " " + m.pattern : "") + " (" + m.priority + (m.block ? ", block" : "") + (m.enabled ? "" : ", 已禁用") + ")"]),
+      toggleButton(e)]));
+
+    const events = await api("GET", "/api/events/recent?limit=50");
+    table("events", ["时间", "self_id", "类型", "用户", "群", "内容"], events.map(e => [
+      new Date(e.time).toLocaleString(), e.self_id, e.type, e.user_id || "", e.group_id || "", e.summary || ""]));
+  } catch (err) {
+    console.error(err);
+  }
+}
+
+async function toggle(name, enable) {
+  await api("POST", "/api/engines/" + encodeURIComponent(name) + (enable ? "/enable" : "/disable"));
+  refresh();
+}
+
+async function send() {
+  try {
+    const data = await api("POST", "/api/send", {
+      self_id: Number($("self_id").value) || 0,
+      user_id: Number($("user_id").value) || 0,
+      group_id: Number($("group_id").value) || 0,
+      message: $("message").value,
+    });
+    $("send_result").textContent = "已发送，消息 ID " + data.message_id;
+  } catch (err) {
+    $("send_result").textContent = "发送失败：" + err.message;
+  }
+}
+
+refresh();
+setInterval(refresh, 5000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,121 @@
+package xbot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xiaoyi510/xbot/api"
+)
+
+// TestAdminServerAddr 测试未设置令牌时只允许监听本机回环地址
+func TestAdminServerAddr(t *testing.T) {
+	manager := &BotManager{}
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:8081": true,
+		"localhost:8081": true,
+		"[::1]:8081":     true,
+		":8081":          false,
+		"0.0.0.0:8081":   false,
+		"10.0.0.1:8081":  false,
+	} {
+		if _, err := NewAdminServer(manager, addr, ""); (err == nil) != ok {
+			t.Errorf("Expected addr %s without token allowed=%v, got err=%v", addr, ok, err)
+		}
+	}
+	if _, err := NewAdminServer(manager, ":8081", "secret"); err != nil {
+		t.Errorf("Expected token to allow any addr, got %v", err)
+	}
+}
+
+// TestAdminServerAPI 测试鉴权、机器人列表、启用/禁用插件和发送消息接口
+func TestAdminServerAPI(t *testing.T) {
+	d := &recordDriver{}
+	manager := &BotManager{}
+	manager.bots.Store(int64(10000), &Bot{SelfID: 10000, Config: &Config{}, API: api.NewClient(d)})
+
+	engine := NewEngine().Name("admin-test")
+	defer unregisterEngine(engine)
+
+	s, err := NewAdminServer(manager, "127.0.0.1:0", "secret")
+	if err != nil {
+		t.Fatalf("NewAdminServer failed: %v", err)
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	do := func(method, path, auth, body string, out interface{}) int {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	// 鉴权：缺少令牌、令牌错误、通过查询参数传递令牌都拒绝
+	for _, c := range []struct{ path, auth string }{
+		{"/api/bots", ""},
+		{"/api/bots", "Bearer wrong"},
+		{"/api/bots", "secret"},
+		{"/api/bots?token=secret", ""},
+	} {
+		if code := do("GET", c.path, c.auth, "", nil); code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for %s with %q, got %d", c.path, c.auth, code)
+		}
+	}
+
+	var bots []struct {
+		SelfID int64 `json:"self_id"`
+	}
+	if code := do("GET", "/api/bots", "Bearer secret", "", &bots); code != http.StatusOK || len(bots) != 1 || bots[0].SelfID != 10000 {
+		t.Errorf("Expected bot list, got %d %v", code, bots)
+	}
+
+	var toggled struct {
+		Enabled bool `json:"enabled"`
+	}
+	if code := do("POST", "/api/engines/admin-test/disable", "Bearer secret", "", &toggled); code != http.StatusOK || toggled.Enabled || engine.IsEnabled() {
+		t.Errorf("Expected engine to be disabled, got %d %v", code, toggled)
+	}
+	if code := do("POST", "/api/engines/admin-test/enable", "Bearer secret", "", &toggled); code != http.StatusOK || !toggled.Enabled || !engine.IsEnabled() {
+		t.Errorf("Expected engine to be enabled, got %d %v", code, toggled)
+	}
+	if code := do("POST", "/api/engines/missing/enable", "Bearer secret", "", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown engine, got %d", code)
+	}
+
+	var sent struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if code := do("POST", "/api/send", "Bearer secret", `{"group_id":123,"message":"hello"}`, &sent); code != http.StatusOK || sent.MessageID != 1 {
+		t.Errorf("Expected message to be sent, got %d %v", code, sent)
+	}
+	if call := d.lastCall(api.ActionSendGroupMsg); call == nil || call["group_id"] != int64(123) {
+		t.Errorf("Expected send_group_msg call, got %v", call)
+	}
+	if code := do("POST", "/api/send", "Bearer secret", `{"message":"hello"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without target, got %d", code)
+	}
+}
+
+// unregisterEngine 从全局引擎列表中移除测试引擎
+func unregisterEngine(engine *Engine) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	for i, e := range globalEngines {
+		if e == engine {
+			globalEngines = append(globalEngines[:i], globalEngines[i+1:]...)
+			return
+		}
+	}
+}
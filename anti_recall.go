@@ -0,0 +1,88 @@
+package xbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/history"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// AntiRecallConfig 防撤回配置
+type AntiRecallConfig struct {
+	AuditGroups []int64 // 撤回消息转发到的审计群，为空时只分发 RecalledMessageEvent
+	IgnoreSelf  bool    // 不转发机器人自己撤回的消息（如敏感词撤回）
+}
+
+// captureRecall 根据消息历史为群消息撤回通知生成附带原消息的 RecalledMessageEvent
+func (bm *BotManager) captureRecall(bot *Bot, evt event.Event) {
+	recall, ok := evt.(*event.GroupRecallNoticeEvent)
+	if !ok || bot.History == nil {
+		return
+	}
+
+	record, ok := bot.History.Find(history.ConversationKey(recall.GroupID, 0), recall.MessageID)
+	if !ok {
+		logger.Debug("撤回的消息不在消息历史中", "groupID", recall.GroupID, "messageID", recall.MessageID)
+		return
+	}
+
+	recalled := &event.RecalledMessageEvent{
+		BaseEvent: event.BaseEvent{
+			Time:     recall.Time,
+			SelfID:   recall.SelfID,
+			PostType: types.PostTypeNotice,
+		},
+		NoticeType: types.NoticeTypeRecalledMessage,
+		GroupID:    recall.GroupID,
+		UserID:     recall.UserID,
+		OperatorID: recall.OperatorID,
+		MessageID:  recall.MessageID,
+		Sender:     record.Sender,
+		SentTime:   record.Time,
+		Message:    record.Message,
+	}
+	bm.handleEvent(recalled)
+
+	if bm.config.AntiRecall == nil || len(bm.config.AntiRecall.AuditGroups) == 0 {
+		return
+	}
+	if bm.config.AntiRecall.IgnoreSelf && recalled.OperatorID == recalled.SelfID {
+		return
+	}
+	go bm.forwardRecalled(bot, recalled)
+}
+
+// forwardRecalled 将撤回的消息转发到审计群
+func (bm *BotManager) forwardRecalled(bot *Bot, evt *event.RecalledMessageEvent) {
+	if bot.API == nil {
+		return
+	}
+
+	name := evt.Sender.Card
+	if name == "" {
+		name = evt.Sender.Nickname
+	}
+	header := fmt.Sprintf("群 %d 中 %s(%d) 于 %s 发送的消息被 %d 撤回：\n",
+		evt.GroupID, name, evt.UserID, time.Unix(evt.SentTime, 0).Format("2006-01-02 15:04:05"), evt.OperatorID)
+
+	// 不转发引用回复，避免在审计群中引用不存在的消息
+	msg := message.Message{message.Text(header)}
+	for _, seg := range evt.Message {
+		if seg.Type != "reply" {
+			msg = append(msg, seg)
+		}
+	}
+
+	for _, groupID := range bm.config.AntiRecall.AuditGroups {
+		if groupID == evt.GroupID {
+			continue
+		}
+		if _, err := bot.API.SendGroupMsg(groupID, msg); err != nil {
+			logger.Warn("转发撤回消息失败", "auditGroup", groupID, "error", err)
+		}
+	}
+}
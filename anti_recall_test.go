@@ -0,0 +1,46 @@
+package xbot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+	"github.com/xiaoyi510/xbot/utils"
+)
+
+// TestCaptureRecall 测试群消息撤回时根据消息历史生成附带原消息的事件
+func TestCaptureRecall(t *testing.T) {
+	bm := &BotManager{
+		config:       &Config{},
+		recentEvents: utils.NewRingBuffer[EventRecord](recentEventsSize),
+	}
+
+	base := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage}
+	bm.handleEvent(&event.GroupMessageEvent{
+		BaseEvent:     base,
+		MessageID:     1,
+		GroupID:       100,
+		UserID:        200,
+		ParsedMessage: message.Message{message.Text("原消息")},
+	})
+
+	base.PostType = types.PostTypeNotice
+	bm.handleEvent(&event.GroupRecallNoticeEvent{BaseEvent: base, GroupID: 100, UserID: 200, OperatorID: 300, MessageID: 1})
+	// 不在消息历史中的消息不生成事件
+	bm.handleEvent(&event.GroupRecallNoticeEvent{BaseEvent: base, GroupID: 100, UserID: 200, OperatorID: 300, MessageID: 2})
+
+	var recalled []EventRecord
+	for _, r := range bm.RecentEvents() {
+		if r.Type == "RecalledMessageEvent" {
+			recalled = append(recalled, r)
+		}
+	}
+	if len(recalled) != 1 {
+		t.Fatalf("Expected 1 recalled message event, got %d", len(recalled))
+	}
+	if recalled[0].UserID != 200 || recalled[0].GroupID != 100 || !strings.Contains(recalled[0].Summary, "原消息") {
+		t.Errorf("Unexpected record: %+v", recalled[0])
+	}
+}
@@ -3,13 +3,22 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/xiaoyi510/xbot/driver"
+	"github.com/xiaoyi510/xbot/metrics"
+	"github.com/xiaoyi510/xbot/trace"
 	"github.com/xiaoyi510/xbot/types"
 )
 
 // Client API 客户端
 type Client struct {
 	driver driver.Driver
+	span   *trace.Span // 父跨度，API 调用会作为它的子跨度
+	policy *Policy     // 重试与熔断策略，nil 表示直接调用驱动器
+	queue  *SendQueue  // 发送队列，nil 表示立即发送
+	onSent func(SentMessage)
 }
 
 // NewClient 创建 API 客户端
@@ -19,67 +28,149 @@ func NewClient(d driver.Driver) *Client {
 	}
 }
 
+// SetPolicy 设置重试与熔断策略
+func (c *Client) SetPolicy(p *Policy) *Client {
+	c.policy = p
+	return c
+}
+
+// Policy 获取重试与熔断策略
+func (c *Client) Policy() *Policy {
+	return c.policy
+}
+
+// WithTrace 返回以 parent 为父跨度的客户端副本
+// 通过副本发起的 API 调用会记录为 parent 的子跨度
+func (c *Client) WithTrace(parent *trace.Span) *Client {
+	if c == nil || parent == nil {
+		return c
+	}
+	clone := *c
+	clone.span = parent
+	return &clone
+}
+
 // CallAPI 调用 API
+// 返回原始响应，retcode 非零时不会返回错误，需要调用方自行检查；
+// 通常应使用 Call 或各个类型化的 API 方法
 func (c *Client) CallAPI(action string, params map[string]interface{}) (*types.APIResponse, error) {
-	return c.driver.CallAPI(action, params)
-}
+	span := trace.StartSpan(c.span, "api."+action)
+	span.SetAttribute("action", action)
+	defer span.End()
 
-// SendPrivateMsg 发送私聊消息
-func (c *Client) SendPrivateMsg(userID int64, message interface{}) (*types.Response[types.MessageResponse], error) {
-	params := map[string]interface{}{
-		"user_id": userID,
-		"message": message,
+	start := time.Now()
+	resp, err := c.invoke(action, params)
+	metrics.APIDuration.Observe(time.Since(start).Seconds(), action)
+
+	// 记录调用次数与失败次数
+	retcode := "error"
+	if err == nil && resp != nil {
+		retcode = strconv.Itoa(resp.RetCode)
+	}
+	metrics.APICalls.Inc(action, retcode)
+
+	// 与 checkResponse 使用相同的成功判断，retcode 1（异步执行）不算失败
+	span.SetAttribute("retcode", retcode)
+	if err != nil {
+		metrics.APIFailures.Inc(action, retcode)
+		span.SetError(err)
+	} else if respErr := checkResponse(action, resp); respErr != nil {
+		metrics.APIFailures.Inc(action, retcode)
+		span.SetStatus(trace.StatusError, respErr.Error())
 	}
 
-	resp, err := c.CallAPI(ActionSendPrivateMsg, params)
+	return resp, err
+}
+
+// Call 调用 API 并将响应数据解析为 T
+// retcode 非零时返回 *Error，Data 通过 JSON 重新编码后解析到 T，字段映射以 T 的 json 标签为准
+func Call[T any](c *Client, action string, params map[string]interface{}) (*types.Response[T], error) {
+	resp, err := c.CallAPI(action, params)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkResponse(action, resp); err != nil {
+		return nil, err
+	}
 
-	var result types.Response[types.MessageResponse]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-	result.Message = resp.Message
-	result.Wording = resp.Wording
+	result := &types.Response[T]{
+		Status:  resp.Status,
+		RetCode: resp.RetCode,
+		Message: resp.Message,
+		Wording: resp.Wording,
+		Echo:    resp.Echo,
+	}
 
 	if resp.Data != nil {
-		if data, ok := resp.Data.(map[string]interface{}); ok {
-			if msgID, ok := data["message_id"].(float64); ok {
-				result.Data.MessageID = int64(msgID)
-			}
+		data, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("编码 %s 响应数据失败: %w", action, err)
+		}
+		if err := json.Unmarshal(data, &result.Data); err != nil {
+			return nil, fmt.Errorf("解析 %s 响应数据失败: %w", action, err)
 		}
 	}
 
-	return &result, nil
+	return result, nil
+}
+
+// do 调用不关心返回数据的 API
+func (c *Client) do(action string, params map[string]interface{}) error {
+	resp, err := c.CallAPI(action, params)
+	if err != nil {
+		return err
+	}
+	return checkResponse(action, resp)
+}
+
+// checkResponse 检查响应状态，失败时返回 *Error
+// 异步处理（retcode 1）视为成功
+func checkResponse(action string, resp *types.APIResponse) error {
+	if resp == nil {
+		return &Error{Action: action, Status: "failed", RetCode: -1, Message: "响应为空"}
+	}
+	if resp.RetCode == RetCodeOK || resp.RetCode == RetCodeAsync {
+		return nil
+	}
+	return &Error{
+		Action:  action,
+		Status:  resp.Status,
+		RetCode: resp.RetCode,
+		Message: resp.Message,
+		Wording: resp.Wording,
+	}
+}
+
+// SendPrivateMsg 发送私聊消息
+// 设置了发送队列时以普通优先级排队，阻塞到实际发送完成
+func (c *Client) SendPrivateMsg(userID int64, message interface{}) (*types.Response[types.MessageResponse], error) {
+	params := map[string]interface{}{
+		"user_id": userID,
+		"message": message,
+	}
+
+	return sendMessage(c, privateTarget(userID), PriorityNormal, ActionSendPrivateMsg, params, messageResponseID)
 }
 
 // SendGroupMsg 发送群消息
+// 设置了发送队列时以普通优先级排队，阻塞到实际发送完成
 func (c *Client) SendGroupMsg(groupID int64, message interface{}) (*types.Response[types.MessageResponse], error) {
 	params := map[string]interface{}{
 		"group_id": groupID,
 		"message":  message,
 	}
 
-	resp, err := c.CallAPI(ActionSendGroupMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[types.MessageResponse]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-	result.Message = resp.Message
-	result.Wording = resp.Wording
+	return sendMessage(c, groupTarget(groupID), PriorityNormal, ActionSendGroupMsg, params, messageResponseID)
+}
 
-	if resp.Data != nil {
-		if data, ok := resp.Data.(map[string]interface{}); ok {
-			if msgID, ok := data["message_id"].(float64); ok {
-				result.Data.MessageID = int64(msgID)
-			}
-		}
-	}
+// messageResponseID 获取发送消息响应中的消息 ID
+func messageResponseID(data types.MessageResponse) int64 {
+	return data.MessageID
+}
 
-	return &result, nil
+// forwardResponseID 获取发送合并转发响应中的消息 ID
+func forwardResponseID(data types.ForwardMessageResponse) int64 {
+	return data.MessageID
 }
 
 // DeleteMsg 撤回消息
@@ -88,8 +179,7 @@ func (c *Client) DeleteMsg(messageID int64) error {
 		"message_id": messageID,
 	}
 
-	_, err := c.CallAPI(ActionDeleteMsg, params)
-	return err
+	return c.do(ActionDeleteMsg, params)
 }
 
 // GetMsg 获取消息
@@ -98,18 +188,7 @@ func (c *Client) GetMsg(messageID int64) (*types.Response[types.MessageData], er
 		"message_id": messageID,
 	}
 
-	resp, err := c.CallAPI(ActionGetMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[types.MessageData]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-
-	// TODO: 解析 Data
-
-	return &result, nil
+	return Call[types.MessageData](c, ActionGetMsg, params)
 }
 
 // SetGroupKick 群组踢人
@@ -123,8 +202,7 @@ func (c *Client) SetGroupKick(groupID, userID int64, rejectAddRequest bool) erro
 		params["reject_add_request"] = true
 	}
 
-	_, err := c.CallAPI(ActionSetGroupKick, params)
-	return err
+	return c.do(ActionSetGroupKick, params)
 }
 
 // SetGroupBan 群组禁言
@@ -135,8 +213,7 @@ func (c *Client) SetGroupBan(groupID, userID int64, duration int32) error {
 		"duration": duration,
 	}
 
-	_, err := c.CallAPI(ActionSetGroupBan, params)
-	return err
+	return c.do(ActionSetGroupBan, params)
 }
 
 // SetGroupWholeBan 群组全员禁言
@@ -146,8 +223,7 @@ func (c *Client) SetGroupWholeBan(groupID int64, enable bool) error {
 		"enable":   enable,
 	}
 
-	_, err := c.CallAPI(ActionSetGroupWholeBan, params)
-	return err
+	return c.do(ActionSetGroupWholeBan, params)
 }
 
 // SetGroupAdmin 设置群管理员
@@ -158,8 +234,7 @@ func (c *Client) SetGroupAdmin(groupID, userID int64, enable bool) error {
 		"enable":   enable,
 	}
 
-	_, err := c.CallAPI(ActionSetGroupAdmin, params)
-	return err
+	return c.do(ActionSetGroupAdmin, params)
 }
 
 // SetGroupCard 设置群名片
@@ -170,40 +245,17 @@ func (c *Client) SetGroupCard(groupID, userID int64, card string) error {
 		"card":     card,
 	}
 
-	_, err := c.CallAPI(ActionSetGroupCard, params)
-	return err
+	return c.do(ActionSetGroupCard, params)
 }
 
 // GetLoginInfo 获取登录号信息
 func (c *Client) GetLoginInfo() (*types.Response[types.LoginInfo], error) {
-	resp, err := c.CallAPI(ActionGetLoginInfo, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[types.LoginInfo]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-
-	// TODO: 解析 Data
-
-	return &result, nil
+	return Call[types.LoginInfo](c, ActionGetLoginInfo, nil)
 }
 
 // GetGroupList 获取群列表
 func (c *Client) GetGroupList() (*types.Response[[]types.GroupInfo], error) {
-	resp, err := c.CallAPI(ActionGetGroupList, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[[]types.GroupInfo]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-
-	// TODO: 解析 Data
-
-	return &result, nil
+	return Call[[]types.GroupInfo](c, ActionGetGroupList, nil)
 }
 
 // GetGroupMemberList 获取群成员列表
@@ -212,52 +264,7 @@ func (c *Client) GetGroupMemberList(groupID int64) (*types.Response[[]types.Grou
 		"group_id": groupID,
 	}
 
-	resp, err := c.CallAPI(ActionGetGroupMemberList, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[[]types.GroupMemberInfo]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-	result.Message = resp.Message
-	result.Wording = resp.Wording
-
-	// 解析群成员列表数据
-	if resp.Data != nil {
-		dataArray, ok := resp.Data.([]interface{})
-		if ok {
-			members := make([]types.GroupMemberInfo, 0, len(dataArray))
-			for _, item := range dataArray {
-				itemMap, ok := item.(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				member := types.GroupMemberInfo{
-					GroupID:         getInt64(itemMap, "group_id"),
-					UserID:          getInt64(itemMap, "user_id"),
-					Nickname:        getString(itemMap, "nickname"),
-					Card:            getString(itemMap, "card"),
-					Sex:             types.Sex(getString(itemMap, "sex")),
-					Age:             int32(getInt64(itemMap, "age")),
-					Area:            getString(itemMap, "area"),
-					JoinTime:        getInt64(itemMap, "join_time"),
-					LastSentTime:    getInt64(itemMap, "last_sent_time"),
-					Level:           getString(itemMap, "level"),
-					Role:            types.Role(getString(itemMap, "role")),
-					Unfriendly:      getBool(itemMap, "unfriendly"),
-					Title:           getString(itemMap, "title"),
-					TitleExpireTime: getInt64(itemMap, "title_expire_time"),
-					CardChangeable:  getBool(itemMap, "card_changeable"),
-				}
-				members = append(members, member)
-			}
-			result.Data = members
-		}
-	}
-
-	return &result, nil
+	return Call[[]types.GroupMemberInfo](c, ActionGetGroupMemberList, params)
 }
 
 // SetGroupAddRequest 处理加群请求
@@ -269,8 +276,19 @@ func (c *Client) SetGroupAddRequest(flag, subType string, approve bool, reason s
 		"reason":   reason,
 	}
 
-	_, err := c.CallAPI(ActionSetGroupAddRequest, params)
-	return err
+	return c.do(ActionSetGroupAddRequest, params)
+}
+
+// SetFriendAddRequest 处理加好友请求
+// remark 为同意时设置的好友备注，仅在 approve 为 true 时有效
+func (c *Client) SetFriendAddRequest(flag string, approve bool, remark string) error {
+	params := map[string]interface{}{
+		"flag":    flag,
+		"approve": approve,
+		"remark":  remark,
+	}
+
+	return c.do(ActionSetFriendAddRequest, params)
 }
 
 // SendGroupNotice 发送群公告
@@ -280,8 +298,7 @@ func (c *Client) SendGroupNotice(groupID int64, content string) error {
 		"content":  content,
 	}
 
-	_, err := c.CallAPI(ActionSendGroupNotice, params)
-	return err
+	return c.do(ActionSendGroupNotice, params)
 }
 
 // SetEssenceMsg 设置精华消息
@@ -290,8 +307,7 @@ func (c *Client) SetEssenceMsg(messageID int64) error {
 		"message_id": messageID,
 	}
 
-	_, err := c.CallAPI(ActionSetEssenceMsg, params)
-	return err
+	return c.do(ActionSetEssenceMsg, params)
 }
 
 // DeleteEssenceMsg 移出精华消息
@@ -300,8 +316,7 @@ func (c *Client) DeleteEssenceMsg(messageID int64) error {
 		"message_id": messageID,
 	}
 
-	_, err := c.CallAPI(ActionDeleteEssenceMsg, params)
-	return err
+	return c.do(ActionDeleteEssenceMsg, params)
 }
 
 // GetEssenceMsgList 获取精华消息列表
@@ -310,45 +325,15 @@ func (c *Client) GetEssenceMsgList(groupID int64) ([]types.EssenceMessage, error
 		"group_id": groupID,
 	}
 
-	resp, err := c.CallAPI(ActionGetEssenceMsgList, params)
+	resp, err := Call[[]types.EssenceMessage](c, ActionGetEssenceMsgList, params)
 	if err != nil {
 		return nil, err
 	}
-
-	// 解析为精华消息列表
 	if resp.Data == nil {
 		return []types.EssenceMessage{}, nil
 	}
 
-	// 尝试解析为数组
-	dataArray, ok := resp.Data.([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid essence message list data format")
-	}
-
-	essenceMessages := make([]types.EssenceMessage, 0, len(dataArray))
-	for _, item := range dataArray {
-		itemMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		essence := types.EssenceMessage{
-			SenderID:     getInt64(itemMap, "sender_id"),
-			SenderNick:   getString(itemMap, "sender_nick"),
-			SenderTime:   getInt64(itemMap, "sender_time"),
-			OperatorID:   getInt64(itemMap, "operator_id"),
-			OperatorNick: getString(itemMap, "operator_nick"),
-			OperatorTime: getInt64(itemMap, "operator_time"),
-			MessageID:    getInt64(itemMap, "message_id"),
-			MessageSeq:   getInt64(itemMap, "msg_seq"),
-			MessageRand:  getInt64(itemMap, "msg_random"),
-			Content:      itemMap["content"], // 保持原始类型，由使用者解析
-		}
-		essenceMessages = append(essenceMessages, essence)
-	}
-
-	return essenceMessages, nil
+	return resp.Data, nil
 }
 
 // ParseEssenceContent 解析精华消息内容为消息段数组
@@ -396,45 +381,6 @@ func GetEssenceContentText(content interface{}) string {
 	return text
 }
 
-// 辅助函数：从 map 中获取 int64
-func getInt64(m map[string]interface{}, key string) int64 {
-	if val, ok := m[key]; ok {
-		switch v := val.(type) {
-		case int64:
-			return v
-		case int:
-			return int64(v)
-		case float64:
-			return int64(v)
-		case json.Number:
-			if i, err := v.Int64(); err == nil {
-				return i
-			}
-		}
-	}
-	return 0
-}
-
-// 辅助函数：从 map 中获取 string
-func getString(m map[string]interface{}, key string) string {
-	if val, ok := m[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
-// 辅助函数：从 map 中获取 bool
-func getBool(m map[string]interface{}, key string) bool {
-	if val, ok := m[key]; ok {
-		if b, ok := val.(bool); ok {
-			return b
-		}
-	}
-	return false
-}
-
 // SendGroupForwardMsg 发送群合并转发消息
 // messages: 消息节点数组，每个节点使用 message.CustomNode() 或 message.Node() 创建
 func (c *Client) SendGroupForwardMsg(groupID int64, messages []interface{}) (*types.Response[types.ForwardMessageResponse], error) {
@@ -443,29 +389,7 @@ func (c *Client) SendGroupForwardMsg(groupID int64, messages []interface{}) (*ty
 		"messages": messages,
 	}
 
-	resp, err := c.CallAPI(ActionSendGroupForwardMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[types.ForwardMessageResponse]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-	result.Message = resp.Message
-	result.Wording = resp.Wording
-
-	if resp.Data != nil {
-		if data, ok := resp.Data.(map[string]interface{}); ok {
-			if msgID, ok := data["message_id"].(float64); ok {
-				result.Data.MessageID = int64(msgID)
-			}
-			if fwdID, ok := data["forward_id"].(string); ok {
-				result.Data.ForwardID = fwdID
-			}
-		}
-	}
-
-	return &result, nil
+	return sendMessage(c, groupTarget(groupID), PriorityNormal, ActionSendGroupForwardMsg, params, forwardResponseID)
 }
 
 // SendPrivateForwardMsg 发送私聊合并转发消息
@@ -476,27 +400,5 @@ func (c *Client) SendPrivateForwardMsg(userID int64, messages []interface{}) (*t
 		"messages": messages,
 	}
 
-	resp, err := c.CallAPI(ActionSendPrivateForwardMsg, params)
-	if err != nil {
-		return nil, err
-	}
-
-	var result types.Response[types.ForwardMessageResponse]
-	result.Status = resp.Status
-	result.RetCode = resp.RetCode
-	result.Message = resp.Message
-	result.Wording = resp.Wording
-
-	if resp.Data != nil {
-		if data, ok := resp.Data.(map[string]interface{}); ok {
-			if msgID, ok := data["message_id"].(float64); ok {
-				result.Data.MessageID = int64(msgID)
-			}
-			if fwdID, ok := data["forward_id"].(string); ok {
-				result.Data.ForwardID = fwdID
-			}
-		}
-	}
-
-	return &result, nil
+	return sendMessage(c, privateTarget(userID), PriorityNormal, ActionSendPrivateForwardMsg, params, forwardResponseID)
 }
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/driver"
+	"github.com/xiaoyi510/xbot/metrics"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// fakeDriver 返回预设响应的驱动器
+type fakeDriver struct {
+	resp  string
+	err   error   // 每次调用都返回的错误
+	errs  []error // 依次返回的错误，用完后返回 resp
+	calls int
+}
+
+func (d *fakeDriver) Connect() error                              { return nil }
+func (d *fakeDriver) SetEventHandler(handler driver.EventHandler) {}
+func (d *fakeDriver) Close() error                                { return nil }
+func (d *fakeDriver) IsConnected() bool                           { return true }
+
+func (d *fakeDriver) CallAPI(action string, params map[string]interface{}) (*types.APIResponse, error) {
+	d.calls++
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.errs) > 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return nil, err
+	}
+	var resp types.APIResponse
+	if err := json.Unmarshal([]byte(d.resp), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TestCallDecodesData 测试响应数据解析
+func TestCallDecodesData(t *testing.T) {
+	c := NewClient(&fakeDriver{resp: `{"status":"ok","retcode":0,"data":{"group_id":123456789012,"group_name":"test","member_count":10}}`})
+
+	resp, err := c.GetGroupInfo(123456789012, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Data.GroupID != 123456789012 || resp.Data.GroupName != "test" || resp.Data.MemberCount != 10 {
+		t.Errorf("Unexpected data: %+v", resp.Data)
+	}
+}
+
+// TestCallReturnsAPIError 测试非零返回码转换为 *Error
+func TestCallReturnsAPIError(t *testing.T) {
+	c := NewClient(&fakeDriver{resp: `{"status":"failed","retcode":100,"message":"","wording":"参数错误"}`})
+
+	_, err := c.SendGroupMsg(1, "hi")
+	if !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("Expected ErrBadRequest, got %v", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Error("Unexpected match with ErrNotFound")
+	}
+	if !errors.Is(err, &Error{RetCode: RetCodeBadParam}) {
+		t.Error("Expected match by retcode")
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Action != ActionSendGroupMsg || apiErr.Wording != "参数错误" {
+		t.Errorf("Unexpected error detail: %+v", apiErr)
+	}
+
+	if err := c.DeleteMsg(1); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest from DeleteMsg, got %v", err)
+	}
+}
+
+// TestPolicyRetry 测试查询类 API 超时重试，发送类 API 不重试
+func TestPolicyRetry(t *testing.T) {
+	policy := NewPolicy().SetReadPolicy(RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond})
+
+	d := &fakeDriver{
+		resp: `{"status":"ok","retcode":0,"data":{"user_id":1,"nickname":"bot"}}`,
+		errs: []error{driver.ErrTimeout, driver.ErrTimeout},
+	}
+	resp, err := NewClient(d).SetPolicy(policy).GetLoginInfo()
+	if err != nil || resp.Data.Nickname != "bot" {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if d.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", d.calls)
+	}
+
+	d = &fakeDriver{
+		resp: `{"status":"ok","retcode":0,"data":{"message_id":1}}`,
+		errs: []error{driver.ErrTimeout},
+	}
+	if _, err := NewClient(d).SetPolicy(policy).SendGroupMsg(1, "hi"); !errors.Is(err, driver.ErrTimeout) {
+		t.Fatalf("Expected timeout without retry, got %v", err)
+	}
+	if d.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", d.calls)
+	}
+}
+
+// TestCircuitBreaker 测试熔断器打开后快速失败，超时后半开探测
+func TestCircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker(2, 20*time.Millisecond)
+	d := &fakeDriver{err: driver.ErrTimeout}
+	c := NewClient(d).SetPolicy(NewPolicy().SetBreaker(breaker))
+
+	c.DeleteMsg(1)
+	c.DeleteMsg(1)
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("Expected open breaker, got %s", breaker.State())
+	}
+	if err := c.DeleteMsg(1); !errors.Is(err, ErrCircuitOpen) || d.calls != 2 {
+		t.Fatalf("Expected fast failure, got %v after %d calls", err, d.calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	d.err = nil
+	d.resp = `{"status":"ok","retcode":0,"data":null}`
+	if err := c.DeleteMsg(1); err != nil {
+		t.Fatalf("Expected probe to succeed, got %v", err)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Errorf("Expected closed breaker, got %s", breaker.State())
+	}
+}
+
+// TestCallAPIFailureMetric 测试 retcode 1（异步执行）不计入失败次数，其它非零返回码计入
+func TestCallAPIFailureMetric(t *testing.T) {
+	async := NewClient(&fakeDriver{resp: `{"status":"async","retcode":1}`})
+	base := metrics.APIFailures.Get("metric_test", "1")
+	if _, err := async.CallAPI("metric_test", map[string]interface{}{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if failures := metrics.APIFailures.Get("metric_test", "1") - base; failures != 0 {
+		t.Errorf("Expected async response not to count as failure, got %v", failures)
+	}
+
+	failed := NewClient(&fakeDriver{resp: `{"status":"failed","retcode":100}`})
+	base = metrics.APIFailures.Get("metric_test", "100")
+	failed.CallAPI("metric_test", map[string]interface{}{})
+	if failures := metrics.APIFailures.Get("metric_test", "100") - base; failures != 1 {
+		t.Errorf("Expected failed response to count as failure, got %v", failures)
+	}
+}
+
+// reconnectDriver 可切换连接状态的驱动器，未连接时返回 driver.ErrNotConnected
+type reconnectDriver struct {
+	connected atomic.Bool
+}
+
+func (d *reconnectDriver) Connect() error                              { return nil }
+func (d *reconnectDriver) SetEventHandler(handler driver.EventHandler) {}
+func (d *reconnectDriver) Close() error                                { return nil }
+func (d *reconnectDriver) IsConnected() bool                           { return d.connected.Load() }
+
+func (d *reconnectDriver) CallAPI(action string, params map[string]interface{}) (*types.APIResponse, error) {
+	if !d.connected.Load() {
+		return nil, driver.ErrNotConnected
+	}
+	return &types.APIResponse{Status: "ok"}, nil
+}
+
+// TestBreakerIgnoresNotConnected 测试断线期间并发调用不会打开熔断器，重连后立即可用
+func TestBreakerIgnoresNotConnected(t *testing.T) {
+	d := &reconnectDriver{}
+	breaker := NewCircuitBreaker(2, time.Minute)
+	policy := NewPolicy().
+		SetWritePolicy(RetryPolicy{WaitReconnect: 20 * time.Millisecond}).
+		SetBreaker(breaker)
+	c := NewClient(d).SetPolicy(policy)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.CallAPI("send_msg", map[string]interface{}{}); !errors.Is(err, driver.ErrNotConnected) {
+				t.Errorf("Expected ErrNotConnected, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("Expected breaker to stay closed while disconnected, got %s", breaker.State())
+	}
+	d.connected.Store(true)
+	if _, err := c.CallAPI("send_msg", map[string]interface{}{}); err != nil {
+		t.Errorf("Expected call to succeed after reconnect, got %v", err)
+	}
+}
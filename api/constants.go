@@ -9,17 +9,38 @@ const (
 	ActionGetMsg         = "get_msg"
 
 	// 群管理API
-	ActionSetGroupKick       = "set_group_kick"
-	ActionSetGroupBan        = "set_group_ban"
-	ActionSetGroupWholeBan   = "set_group_whole_ban"
-	ActionSetGroupAdmin      = "set_group_admin"
-	ActionSetGroupCard       = "set_group_card"
-	ActionSetGroupAddRequest = "set_group_add_request"
+	ActionSetGroupKick         = "set_group_kick"
+	ActionSetGroupBan          = "set_group_ban"
+	ActionSetGroupWholeBan     = "set_group_whole_ban"
+	ActionSetGroupAdmin        = "set_group_admin"
+	ActionSetGroupCard         = "set_group_card"
+	ActionSetGroupAddRequest   = "set_group_add_request"
+	ActionSetGroupName         = "set_group_name"
+	ActionSetGroupLeave        = "set_group_leave"
+	ActionSetGroupSpecialTitle = "set_group_special_title"
+	ActionSetGroupAnonymousBan = "set_group_anonymous_ban"
+
+	// 好友API
+	ActionSetFriendAddRequest = "set_friend_add_request"
 
 	// 信息获取API
 	ActionGetLoginInfo       = "get_login_info"
 	ActionGetGroupList       = "get_group_list"
 	ActionGetGroupMemberList = "get_group_member_list"
+	ActionGetStrangerInfo    = "get_stranger_info"
+	ActionGetFriendList      = "get_friend_list"
+	ActionGetGroupInfo       = "get_group_info"
+	ActionGetGroupMemberInfo = "get_group_member_info"
+	ActionGetGroupHonorInfo  = "get_group_honor_info"
+	ActionGetStatus          = "get_status"
+	ActionGetVersionInfo     = "get_version_info"
+	ActionCanSendImage       = "can_send_image"
+	ActionCanSendRecord      = "can_send_record"
+
+	// 媒体与转发消息API
+	ActionGetRecord     = "get_record"
+	ActionGetImage      = "get_image"
+	ActionGetForwardMsg = "get_forward_msg"
 
 	// 群公告和精华消息API
 	ActionSendGroupNotice   = "_send_group_notice"
@@ -30,4 +51,15 @@ const (
 	// 合并转发API
 	ActionSendGroupForwardMsg   = "send_group_forward_msg"
 	ActionSendPrivateForwardMsg = "send_private_forward_msg"
+
+	// 群文件API（go-cqhttp 扩展）
+	ActionUploadGroupFile        = "upload_group_file"
+	ActionDeleteGroupFile        = "delete_group_file"
+	ActionCreateGroupFileFolder  = "create_group_file_folder"
+	ActionDeleteGroupFolder      = "delete_group_folder"
+	ActionGetGroupFileSystemInfo = "get_group_file_system_info"
+	ActionGetGroupRootFiles      = "get_group_root_files"
+	ActionGetGroupFilesByFolder  = "get_group_files_by_folder"
+	ActionGetGroupFileURL        = "get_group_file_url"
+	ActionUploadPrivateFile      = "upload_private_file"
 )
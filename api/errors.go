@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 常见的 OneBot 返回码
+// 1xx 为 go-cqhttp 等实现使用的返回码，14xx 为 OneBot v11 HTTP 状态码对应的返回码
+const (
+	RetCodeOK           = 0    // 成功
+	RetCodeAsync        = 1    // 已提交异步处理
+	RetCodeBadParam     = 100  // 参数缺失或无效
+	RetCodeInvalidData  = 102  // 数据无效（如消息内容为空、目标不存在）
+	RetCodeFailed       = 103  // 操作失败（如发送失败、权限不足）
+	RetCodeUnauthorized = 104  // 登录状态失效
+	RetCodeBadRequest   = 1400 // 请求格式错误
+	RetCodeForbidden    = 1401 // 鉴权失败
+	RetCodeAccessDenied = 1403 // 无权访问
+	RetCodeNotFound     = 1404 // API 不存在
+)
+
+// 可用于 errors.Is 判断的常见错误
+var (
+	ErrBadRequest   = errors.New("请求参数错误")    // 对应 100、1400
+	ErrInvalidData  = errors.New("数据无效")      // 对应 102
+	ErrFailed       = errors.New("操作失败")      // 对应 103
+	ErrUnauthorized = errors.New("鉴权失败或登录失效") // 对应 104、1401、1403
+	ErrNotFound     = errors.New("API 不存在")   // 对应 1404
+)
+
+// Error API 调用失败错误
+// OneBot 返回非零 retcode 时由 Call 以及各 API 方法返回，可通过 errors.As 获取详细信息：
+//
+//	var apiErr *api.Error
+//	if errors.As(err, &apiErr) {
+//		fmt.Println(apiErr.RetCode, apiErr.Wording)
+//	}
+type Error struct {
+	Action  string // API 名称
+	Status  string // 状态，通常为 failed
+	RetCode int    // 返回码
+	Message string // 错误信息
+	Wording string // 错误信息（部分实现使用该字段提供更友好的描述）
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Wording
+	}
+	if msg == "" {
+		msg = e.Status
+	}
+	return fmt.Sprintf("API %s 调用失败 (retcode=%d): %s", e.Action, e.RetCode, msg)
+}
+
+// Is 支持 errors.Is 判断常见错误
+// target 为 *Error 时比较返回码，Action 不为空时同时比较 API 名称
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return e.RetCode == t.RetCode && (t.Action == "" || e.Action == t.Action)
+	}
+
+	switch target {
+	case ErrBadRequest:
+		return e.RetCode == RetCodeBadParam || e.RetCode == RetCodeBadRequest
+	case ErrInvalidData:
+		return e.RetCode == RetCodeInvalidData
+	case ErrFailed:
+		return e.RetCode == RetCodeFailed
+	case ErrUnauthorized:
+		return e.RetCode == RetCodeUnauthorized || e.RetCode == RetCodeForbidden || e.RetCode == RetCodeAccessDenied
+	case ErrNotFound:
+		return e.RetCode == RetCodeNotFound
+	}
+	return false
+}
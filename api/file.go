@@ -0,0 +1,105 @@
+package api
+
+import (
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// ========== 群文件（go-cqhttp 扩展） ==========
+
+// UploadGroupFile 上传群文件
+// file 为本地文件路径，folder 为父目录 ID，为空时上传到根目录
+func (c *Client) UploadGroupFile(groupID int64, file, name, folder string) error {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"file":     file,
+		"name":     name,
+	}
+	if folder != "" {
+		params["folder"] = folder
+	}
+
+	return c.do(ActionUploadGroupFile, params)
+}
+
+// UploadPrivateFile 上传私聊文件
+// file 为本地文件路径
+func (c *Client) UploadPrivateFile(userID int64, file, name string) error {
+	params := map[string]interface{}{
+		"user_id": userID,
+		"file":    file,
+		"name":    name,
+	}
+
+	return c.do(ActionUploadPrivateFile, params)
+}
+
+// DeleteGroupFile 删除群文件
+func (c *Client) DeleteGroupFile(groupID int64, fileID string, busID int64) error {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"file_id":  fileID,
+		"busid":    busID,
+	}
+
+	return c.do(ActionDeleteGroupFile, params)
+}
+
+// CreateGroupFileFolder 创建群文件夹（仅能在根目录创建）
+func (c *Client) CreateGroupFileFolder(groupID int64, name string) error {
+	params := map[string]interface{}{
+		"group_id":  groupID,
+		"name":      name,
+		"parent_id": "/",
+	}
+
+	return c.do(ActionCreateGroupFileFolder, params)
+}
+
+// DeleteGroupFolder 删除群文件夹
+func (c *Client) DeleteGroupFolder(groupID int64, folderID string) error {
+	params := map[string]interface{}{
+		"group_id":  groupID,
+		"folder_id": folderID,
+	}
+
+	return c.do(ActionDeleteGroupFolder, params)
+}
+
+// GetGroupFileSystemInfo 获取群文件系统信息
+func (c *Client) GetGroupFileSystemInfo(groupID int64) (*types.Response[types.GroupFileSystemInfo], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+	}
+
+	return Call[types.GroupFileSystemInfo](c, ActionGetGroupFileSystemInfo, params)
+}
+
+// GetGroupRootFiles 获取群根目录文件列表
+func (c *Client) GetGroupRootFiles(groupID int64) (*types.Response[types.GroupFiles], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+	}
+
+	return Call[types.GroupFiles](c, ActionGetGroupRootFiles, params)
+}
+
+// GetGroupFilesByFolder 获取群子目录文件列表
+func (c *Client) GetGroupFilesByFolder(groupID int64, folderID string) (*types.Response[types.GroupFiles], error) {
+	params := map[string]interface{}{
+		"group_id":  groupID,
+		"folder_id": folderID,
+	}
+
+	return Call[types.GroupFiles](c, ActionGetGroupFilesByFolder, params)
+}
+
+// GetGroupFileURL 获取群文件下载链接
+func (c *Client) GetGroupFileURL(groupID int64, fileID string, busID int64) (*types.Response[types.GroupFileURL], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"file_id":  fileID,
+		"busid":    busID,
+	}
+
+	return Call[types.GroupFileURL](c, ActionGetGroupFileURL, params)
+}
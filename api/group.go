@@ -0,0 +1,47 @@
+package api
+
+// SetGroupName 设置群名
+func (c *Client) SetGroupName(groupID int64, name string) error {
+	params := map[string]interface{}{
+		"group_id":   groupID,
+		"group_name": name,
+	}
+
+	return c.do(ActionSetGroupName, params)
+}
+
+// SetGroupLeave 退出群组
+// isDismiss 为 true 且登录号是群主时解散该群
+func (c *Client) SetGroupLeave(groupID int64, isDismiss bool) error {
+	params := map[string]interface{}{
+		"group_id":   groupID,
+		"is_dismiss": isDismiss,
+	}
+
+	return c.do(ActionSetGroupLeave, params)
+}
+
+// SetGroupSpecialTitle 设置群组专属头衔（需要群主权限）
+// title 为空表示删除专属头衔，duration 为有效期（秒），-1 表示永久
+func (c *Client) SetGroupSpecialTitle(groupID, userID int64, title string, duration int64) error {
+	params := map[string]interface{}{
+		"group_id":      groupID,
+		"user_id":       userID,
+		"special_title": title,
+		"duration":      duration,
+	}
+
+	return c.do(ActionSetGroupSpecialTitle, params)
+}
+
+// SetGroupAnonymousBan 群组匿名用户禁言
+// flag 为匿名消息中 Anonymous.Flag 的值，duration 为禁言时长（秒），无法取消匿名用户禁言
+func (c *Client) SetGroupAnonymousBan(groupID int64, flag string, duration int32) error {
+	params := map[string]interface{}{
+		"group_id":       groupID,
+		"anonymous_flag": flag,
+		"duration":       duration,
+	}
+
+	return c.do(ActionSetGroupAnonymousBan, params)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// GetStrangerInfo 获取陌生人信息
+// noCache 为 true 时不使用缓存（响应更慢，但数据更新）
+func (c *Client) GetStrangerInfo(userID int64, noCache bool) (*types.Response[types.UserInfo], error) {
+	params := map[string]interface{}{
+		"user_id":  userID,
+		"no_cache": noCache,
+	}
+
+	return Call[types.UserInfo](c, ActionGetStrangerInfo, params)
+}
+
+// GetFriendList 获取好友列表
+func (c *Client) GetFriendList() (*types.Response[[]types.FriendInfo], error) {
+	return Call[[]types.FriendInfo](c, ActionGetFriendList, nil)
+}
+
+// GetGroupInfo 获取群信息
+func (c *Client) GetGroupInfo(groupID int64, noCache bool) (*types.Response[types.GroupInfo], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"no_cache": noCache,
+	}
+
+	return Call[types.GroupInfo](c, ActionGetGroupInfo, params)
+}
+
+// GetGroupMemberInfo 获取群成员信息
+func (c *Client) GetGroupMemberInfo(groupID, userID int64, noCache bool) (*types.Response[types.GroupMemberInfo], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+		"no_cache": noCache,
+	}
+
+	return Call[types.GroupMemberInfo](c, ActionGetGroupMemberInfo, params)
+}
+
+// GetGroupHonorInfo 获取群荣誉信息
+// honorType 为 types.HonorTypeAll 时返回所有类型
+func (c *Client) GetGroupHonorInfo(groupID int64, honorType types.HonorType) (*types.Response[types.GroupHonorData], error) {
+	params := map[string]interface{}{
+		"group_id": groupID,
+		"type":     honorType,
+	}
+
+	return Call[types.GroupHonorData](c, ActionGetGroupHonorInfo, params)
+}
+
+// GetStatus 获取 OneBot 运行状态
+func (c *Client) GetStatus() (*types.Response[types.Status], error) {
+	return Call[types.Status](c, ActionGetStatus, nil)
+}
+
+// GetVersionInfo 获取 OneBot 版本信息
+func (c *Client) GetVersionInfo() (*types.Response[types.VersionInfo], error) {
+	return Call[types.VersionInfo](c, ActionGetVersionInfo, nil)
+}
+
+// CanSendImage 检查是否可以发送图片
+func (c *Client) CanSendImage() (bool, error) {
+	resp, err := Call[types.CanSendInfo](c, ActionCanSendImage, nil)
+	if err != nil {
+		return false, err
+	}
+	return resp.Data.Yes, nil
+}
+
+// CanSendRecord 检查是否可以发送语音
+func (c *Client) CanSendRecord() (bool, error) {
+	resp, err := Call[types.CanSendInfo](c, ActionCanSendRecord, nil)
+	if err != nil {
+		return false, err
+	}
+	return resp.Data.Yes, nil
+}
+
+// GetRecord 获取语音
+// file 为收到的语音消息段中的 file 参数，outFormat 为要转换成的格式，如 mp3、amr、wav
+func (c *Client) GetRecord(file, outFormat string) (*types.Response[types.RecordInfo], error) {
+	params := map[string]interface{}{
+		"file":       file,
+		"out_format": outFormat,
+	}
+
+	return Call[types.RecordInfo](c, ActionGetRecord, params)
+}
+
+// GetImage 获取图片
+// file 为收到的图片消息段中的 file 参数
+func (c *Client) GetImage(file string) (*types.Response[types.ImageInfo], error) {
+	params := map[string]interface{}{
+		"file": file,
+	}
+
+	return Call[types.ImageInfo](c, ActionGetImage, params)
+}
+
+// GetForwardMsg 获取合并转发消息
+// id 为合并转发消息段中的 id 参数
+func (c *Client) GetForwardMsg(id string) (*types.Response[types.ForwardMessageData], error) {
+	params := map[string]interface{}{
+		"id":         id,
+		"message_id": id, // 部分实现使用 message_id 参数
+	}
+
+	return Call[types.ForwardMessageData](c, ActionGetForwardMsg, params)
+}
@@ -0,0 +1,317 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/driver"
+	"github.com/xiaoyi510/xbot/metrics"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// ErrCircuitOpen 熔断器已打开，OneBot 持续不可用时快速失败
+var ErrCircuitOpen = errors.New("熔断器已打开，OneBot 暂不可用")
+
+// RetryPolicy 单个 API 的重试策略
+type RetryPolicy struct {
+	MaxRetries     int           // 超时等传输错误的最大重试次数，0 表示不重试
+	InitialBackoff time.Duration // 首次重试前的等待时间，之后按指数增长
+	MaxBackoff     time.Duration // 最大等待时间
+	WaitReconnect  time.Duration // 驱动器未连接时等待重连的最长时间，0 表示不等待
+}
+
+// backoff 计算第 attempt 次重试前的等待时间（attempt 从 0 开始）
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	d := r.InitialBackoff
+	for i := 0; i < attempt && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	return d
+}
+
+// Policy API 调用策略
+// 查询类 API（get_、can_ 开头）默认使用 read 策略，其余使用 write 策略，
+// 也可以通过 SetActionPolicy 为单个 API 单独配置。
+//
+// 未连接错误表示请求没有发出，在 WaitReconnect 时间内总会等待重连后重发；
+// 超时错误表示请求可能已被执行，只有配置了 MaxRetries 的 API 才会重试，
+// 因此发送消息等非幂等 API 默认不应配置 MaxRetries，避免重复发送。
+type Policy struct {
+	mu      sync.RWMutex
+	read    RetryPolicy
+	write   RetryPolicy
+	actions map[string]RetryPolicy
+	breaker *CircuitBreaker
+}
+
+// NewPolicy 创建空策略（不重试、不等待重连、不熔断）
+func NewPolicy() *Policy {
+	return &Policy{
+		actions: make(map[string]RetryPolicy),
+	}
+}
+
+// DefaultPolicy 创建默认策略
+// 所有 API 在未连接时最多等待 10 秒重连；查询类 API 超时后最多重试 2 次；
+// 连接正常时连续 5 次传输失败后熔断 30 秒，未连接不计入熔断
+func DefaultPolicy() *Policy {
+	return NewPolicy().
+		SetReadPolicy(RetryPolicy{
+			MaxRetries:     2,
+			InitialBackoff: 500 * time.Millisecond,
+			MaxBackoff:     5 * time.Second,
+			WaitReconnect:  10 * time.Second,
+		}).
+		SetWritePolicy(RetryPolicy{
+			WaitReconnect: 10 * time.Second,
+		}).
+		SetBreaker(NewCircuitBreaker(5, 30*time.Second))
+}
+
+// SetReadPolicy 设置查询类 API 的默认策略
+func (p *Policy) SetReadPolicy(r RetryPolicy) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.read = r
+	return p
+}
+
+// SetWritePolicy 设置非查询类 API 的默认策略
+func (p *Policy) SetWritePolicy(r RetryPolicy) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write = r
+	return p
+}
+
+// SetActionPolicy 为指定 API 设置策略，优先于默认策略
+func (p *Policy) SetActionPolicy(action string, r RetryPolicy) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions[action] = r
+	return p
+}
+
+// SetBreaker 设置熔断器，nil 表示不熔断
+func (p *Policy) SetBreaker(b *CircuitBreaker) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.breaker = b
+	return p
+}
+
+// Breaker 获取熔断器
+func (p *Policy) Breaker() *CircuitBreaker {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.breaker
+}
+
+// For 获取指定 API 的策略
+func (p *Policy) For(action string) RetryPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if r, ok := p.actions[action]; ok {
+		return r
+	}
+	if isReadAction(action) {
+		return p.read
+	}
+	return p.write
+}
+
+// isReadAction 是否是查询类 API
+func isReadAction(action string) bool {
+	action = strings.TrimPrefix(action, "_")
+	return strings.HasPrefix(action, "get_") || strings.HasPrefix(action, "can_")
+}
+
+// invoke 按策略调用驱动器
+func (c *Client) invoke(action string, params map[string]interface{}) (*types.APIResponse, error) {
+	if c.policy == nil {
+		return c.driver.CallAPI(action, params)
+	}
+
+	rp := c.policy.For(action)
+	breaker := c.policy.Breaker()
+	reconnectDeadline := time.Now().Add(rp.WaitReconnect)
+
+	for attempt := 0; ; {
+		if err := breaker.Allow(); err != nil {
+			return nil, err
+		}
+
+		// 未连接时先等待重连，避免请求必然失败
+		if rp.WaitReconnect > 0 && !c.driver.IsConnected() {
+			waitConnected(c.driver, reconnectDeadline)
+		}
+
+		resp, err := c.driver.CallAPI(action, params)
+		if err == nil {
+			breaker.Success()
+			return resp, nil
+		}
+		// 未连接时请求没有发出，不说明 OneBot 不可用，不计入熔断；
+		// 否则断线超过 WaitReconnect 时并发调用会打开熔断器，重连后反而快速失败
+		if errors.Is(err, driver.ErrNotConnected) {
+			breaker.Cancel()
+		} else {
+			breaker.Failure()
+		}
+
+		// 请求没有发出，在等待窗口内可以安全地重发
+		if errors.Is(err, driver.ErrNotConnected) && time.Now().Before(reconnectDeadline) {
+			metrics.APIRetries.Inc(action)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		if attempt >= rp.MaxRetries {
+			return nil, err
+		}
+
+		time.Sleep(rp.backoff(attempt))
+		attempt++
+		metrics.APIRetries.Inc(action)
+	}
+}
+
+// waitConnected 等待驱动器重新连接，直到 deadline
+func waitConnected(d driver.Driver, deadline time.Time) {
+	for !d.IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// ========== 熔断器 ==========
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭：正常放行
+	BreakerOpen                         // 打开：快速失败
+	BreakerHalfOpen                     // 半开：放行一个探测请求
+)
+
+// String 返回状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker 熔断器
+// 连接正常时连续传输失败（如超时）达到阈值后打开，打开期间所有调用直接返回 ErrCircuitOpen；
+// 超过 openTimeout 后进入半开状态放行一个探测请求，成功则关闭，失败则重新打开。
+// 所有方法对 nil 熔断器都是安全的空操作。
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	failures    int
+	state       BreakerState
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewCircuitBreaker 创建熔断器
+// threshold 为连续失败阈值，openTimeout 为打开状态的持续时间
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if openTimeout <= 0 {
+		openTimeout = 30 * time.Second
+	}
+	return &CircuitBreaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Allow 检查是否允许调用
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success 记录一次成功调用
+func (b *CircuitBreaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.state = BreakerClosed
+	b.probing = false
+}
+
+// Failure 记录一次失败调用
+func (b *CircuitBreaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Cancel 记录一次没有发出的调用（如驱动器未连接），不计入成功或失败；
+// 半开状态下释放探测名额，让下一次调用继续探测
+func (b *CircuitBreaker) Cancel() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State 获取当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,433 @@
+package api
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/metrics"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// 发送队列错误
+var (
+	ErrQueueFull   = errors.New("发送队列已满")
+	ErrQueueClosed = errors.New("发送队列已关闭")
+)
+
+// Priority 发送优先级
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // 低优先级，如批量推送
+	PriorityNormal                 // 普通优先级，默认
+	PriorityHigh                   // 高优先级，如命令回复、告警
+)
+
+// String 优先级名称，用作指标标签
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// SendQueueConfig 发送队列配置
+type SendQueueConfig struct {
+	TargetInterval time.Duration // 同一目标（群/私聊）两条消息的最小间隔，0 表示不限制
+	AccountLimit   int           // 账号在 AccountWindow 内最多发送的消息数，0 表示不限制
+	AccountWindow  time.Duration // 账号限流时间窗口
+	Jitter         time.Duration // 每条消息发送前附加的随机延迟上限，0 表示不附加
+	Size           int           // 每个优先级队列的最大长度，默认 1000
+}
+
+// DefaultSendQueueConfig 默认发送队列配置：每个目标每秒 1 条，每个账号每分钟 20 条
+func DefaultSendQueueConfig() SendQueueConfig {
+	return SendQueueConfig{
+		TargetInterval: time.Second,
+		AccountLimit:   20,
+		AccountWindow:  time.Minute,
+		Size:           1000,
+	}
+}
+
+// SendFuture 排队发送的结果
+type SendFuture struct {
+	done      chan struct{}
+	messageID int64
+	err       error
+}
+
+// newSendFuture 创建未完成的结果
+func newSendFuture() *SendFuture {
+	return &SendFuture{done: make(chan struct{})}
+}
+
+// resolve 设置结果
+func (f *SendFuture) resolve(messageID int64, err error) {
+	f.messageID = messageID
+	f.err = err
+	close(f.done)
+}
+
+// Done 返回消息实际发送（或失败）后关闭的通道
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 阻塞等待消息实际发送，返回消息 ID
+func (f *SendFuture) Wait() (int64, error) {
+	<-f.done
+	return f.messageID, f.err
+}
+
+// Then 注册回调，消息实际发送（或失败）后在新的协程中调用
+func (f *SendFuture) Then(callback func(messageID int64, err error)) *SendFuture {
+	go func() {
+		callback(f.Wait())
+	}()
+	return f
+}
+
+// sendJob 排队中的发送任务
+type sendJob struct {
+	target string
+	exec   func() (int64, error)
+	future *SendFuture
+}
+
+// SendQueue 发送队列
+// 每个账号（Client）使用一个队列，由单独的协程按优先级依次发送，
+// 同时满足单个目标的发送间隔和账号的发送频率限制，降低被风控的概率。
+// 同一优先级内同一目标的消息保持先后顺序。
+type SendQueue struct {
+	config SendQueueConfig
+
+	mu       sync.Mutex
+	lanes    [PriorityHigh + 1][]*sendJob
+	lastSent map[string]time.Time // 目标 -> 最近发送时间
+	history  []time.Time          // 账号在时间窗口内的发送时间
+	notify   chan struct{}
+	closed   bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewSendQueue 创建发送队列，需要调用 Start 启动
+func NewSendQueue(config SendQueueConfig) *SendQueue {
+	if config.Size <= 0 {
+		config.Size = 1000
+	}
+	if config.AccountLimit > 0 && config.AccountWindow <= 0 {
+		config.AccountWindow = time.Minute
+	}
+	return &SendQueue{
+		config:   config,
+		lastSent: make(map[string]time.Time),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start 启动发送协程
+func (q *SendQueue) Start() *SendQueue {
+	go q.run()
+	return q
+}
+
+// Close 关闭队列，尚未发送的消息返回 ErrQueueClosed
+func (q *SendQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	var pending []*sendJob
+	for i := range q.lanes {
+		metrics.SendQueueDepth.Add(-float64(len(q.lanes[i])), Priority(i).String())
+		pending = append(pending, q.lanes[i]...)
+		q.lanes[i] = nil
+	}
+	q.mu.Unlock()
+
+	close(q.stop)
+	for _, job := range pending {
+		job.future.resolve(0, ErrQueueClosed)
+	}
+}
+
+// Len 获取排队中的消息数量
+func (q *SendQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for i := range q.lanes {
+		n += len(q.lanes[i])
+	}
+	return n
+}
+
+// submit 提交发送任务
+func (q *SendQueue) submit(target string, priority Priority, exec func() (int64, error)) *SendFuture {
+	future := newSendFuture()
+	if priority < PriorityLow || priority > PriorityHigh {
+		priority = PriorityNormal
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		future.resolve(0, ErrQueueClosed)
+		return future
+	}
+	if len(q.lanes[priority]) >= q.config.Size {
+		q.mu.Unlock()
+		future.resolve(0, ErrQueueFull)
+		return future
+	}
+	q.lanes[priority] = append(q.lanes[priority], &sendJob{
+		target: target,
+		exec:   exec,
+		future: future,
+	})
+	metrics.SendQueueDepth.Inc(priority.String())
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return future
+}
+
+// run 发送循环
+func (q *SendQueue) run() {
+	defer close(q.stopped)
+
+	for {
+		job, wait := q.next(time.Now())
+		if job == nil {
+			var timer <-chan time.Time
+			if wait > 0 {
+				timer = time.After(wait)
+			}
+			select {
+			case <-q.notify:
+			case <-timer:
+			case <-q.stop:
+				return
+			}
+			continue
+		}
+
+		if q.config.Jitter > 0 {
+			select {
+			case <-time.After(rand.N(q.config.Jitter)):
+			case <-q.stop:
+				job.future.resolve(0, ErrQueueClosed)
+				return
+			}
+		}
+
+		messageID, err := job.exec()
+		q.markSent(job.target, time.Now())
+		job.future.resolve(messageID, err)
+	}
+}
+
+// next 取出下一条可以发送的消息
+// 没有可发送的消息时返回需要等待的时间，0 表示等待新消息
+func (q *SendQueue) next(now time.Time) (*sendJob, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// 账号限流
+	if q.config.AccountLimit > 0 {
+		cutoff := now.Add(-q.config.AccountWindow)
+		i := 0
+		for i < len(q.history) && !q.history[i].After(cutoff) {
+			i++
+		}
+		q.history = q.history[i:]
+
+		if len(q.history) >= q.config.AccountLimit {
+			return nil, q.history[0].Add(q.config.AccountWindow).Sub(now)
+		}
+	}
+
+	// 按优先级从高到低查找目标已就绪的消息
+	var wait time.Duration
+	for p := PriorityHigh; p >= PriorityLow; p-- {
+		lane := q.lanes[p]
+		blocked := make(map[string]bool)
+		for i, job := range lane {
+			if blocked[job.target] {
+				continue
+			}
+			if ready := q.lastSent[job.target].Add(q.config.TargetInterval); ready.After(now) {
+				blocked[job.target] = true
+				if d := ready.Sub(now); wait == 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+			q.lanes[p] = append(lane[:i:i], lane[i+1:]...)
+			metrics.SendQueueDepth.Dec(p.String())
+			return job, 0
+		}
+	}
+
+	return nil, wait
+}
+
+// markSent 记录发送时间
+func (q *SendQueue) markSent(target string, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.config.TargetInterval > 0 {
+		q.lastSent[target] = now
+		// 清理已过间隔的目标，避免长期运行后占用过多内存
+		if len(q.lastSent) > 1024 {
+			for t, last := range q.lastSent {
+				if now.Sub(last) > q.config.TargetInterval {
+					delete(q.lastSent, t)
+				}
+			}
+		}
+	}
+	if q.config.AccountLimit > 0 {
+		q.history = append(q.history, now)
+	}
+}
+
+// ========== Client 接入 ==========
+
+// SetSendQueue 设置发送队列，设置后发送消息的 API 会排队发送
+func (c *Client) SetSendQueue(q *SendQueue) *Client {
+	c.queue = q
+	return c
+}
+
+// SendQueue 获取发送队列，未设置时返回 nil
+func (c *Client) SendQueue() *SendQueue {
+	return c.queue
+}
+
+// enqueue 将发送任务加入队列，未设置队列时立即发送
+func (c *Client) enqueue(target string, priority Priority, exec func() (int64, error)) *SendFuture {
+	if c.queue == nil {
+		future := newSendFuture()
+		future.resolve(exec())
+		return future
+	}
+	return c.queue.submit(target, priority, exec)
+}
+
+// groupTarget 群聊目标标识
+func groupTarget(groupID int64) string {
+	return "group:" + strconv.FormatInt(groupID, 10)
+}
+
+// privateTarget 私聊目标标识
+func privateTarget(userID int64) string {
+	return "private:" + strconv.FormatInt(userID, 10)
+}
+
+// sendMessage 排队发送消息并等待结果
+func sendMessage[T any](c *Client, target string, priority Priority, action string, params map[string]interface{}, messageID func(T) int64) (*types.Response[T], error) {
+	var resp *types.Response[T]
+	_, err := c.enqueue(target, priority, func() (int64, error) {
+		var err error
+		resp, err = Call[T](c, action, params)
+		if err != nil {
+			return 0, err
+		}
+		c.notifySent(action, params, messageID(resp.Data))
+		return messageID(resp.Data), nil
+	}).Wait()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendPrivateMsgAsync 以指定优先级排队发送私聊消息，返回的 SendFuture 在实际发送后给出消息 ID
+// 未设置发送队列时在新的协程中立即发送
+func (c *Client) SendPrivateMsgAsync(userID int64, message interface{}, priority Priority) *SendFuture {
+	return c.sendAsync(privateTarget(userID), priority, ActionSendPrivateMsg, map[string]interface{}{
+		"user_id": userID,
+		"message": message,
+	})
+}
+
+// SendGroupMsgAsync 以指定优先级排队发送群消息，返回的 SendFuture 在实际发送后给出消息 ID
+// 未设置发送队列时在新的协程中立即发送
+func (c *Client) SendGroupMsgAsync(groupID int64, message interface{}, priority Priority) *SendFuture {
+	return c.sendAsync(groupTarget(groupID), priority, ActionSendGroupMsg, map[string]interface{}{
+		"group_id": groupID,
+		"message":  message,
+	})
+}
+
+// sendAsync 异步发送消息
+func (c *Client) sendAsync(target string, priority Priority, action string, params map[string]interface{}) *SendFuture {
+	exec := func() (int64, error) {
+		resp, err := Call[types.MessageResponse](c, action, params)
+		if err != nil {
+			return 0, err
+		}
+		c.notifySent(action, params, resp.Data.MessageID)
+		return resp.Data.MessageID, nil
+	}
+
+	if c.queue != nil {
+		return c.queue.submit(target, priority, exec)
+	}
+
+	future := newSendFuture()
+	go func() {
+		future.resolve(exec())
+	}()
+	return future
+}
+
+// SentMessage 已发送的消息
+type SentMessage struct {
+	Action    string      // 发送使用的 API，如 send_group_msg、send_group_forward_msg
+	GroupID   int64       // 群号，私聊为 0
+	UserID    int64       // 私聊对象 QQ 号，群聊为 0
+	MessageID int64       // 消息 ID
+	Message   interface{} // 消息内容，合并转发为消息节点列表
+}
+
+// OnMessageSent 设置消息发送成功后的回调，用于记录机器人自己发送的消息
+func (c *Client) OnMessageSent(callback func(SentMessage)) *Client {
+	c.onSent = callback
+	return c
+}
+
+// notifySent 通知消息已发送
+func (c *Client) notifySent(action string, params map[string]interface{}, messageID int64) {
+	if c.onSent == nil {
+		return
+	}
+
+	sent := SentMessage{Action: action, MessageID: messageID}
+	sent.GroupID, _ = params["group_id"].(int64)
+	sent.UserID, _ = params["user_id"].(int64)
+	if msg, ok := params["message"]; ok {
+		sent.Message = msg
+	} else {
+		sent.Message = params["messages"]
+	}
+	c.onSent(sent)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/metrics"
+)
+
+// TestSendQueueOrdering 测试优先级与同一目标的发送间隔
+func TestSendQueueOrdering(t *testing.T) {
+	q := NewSendQueue(SendQueueConfig{TargetInterval: 30 * time.Millisecond})
+	defer q.Close()
+
+	var mu sync.Mutex
+	var order []string
+	var times []time.Time
+	exec := func(name string) func() (int64, error) {
+		return func() (int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			times = append(times, time.Now())
+			return int64(len(order)), nil
+		}
+	}
+
+	baseDepth := metrics.SendQueueDepth.Get("low")
+
+	// 启动前提交，保证按优先级取出
+	low := q.submit("group:1", PriorityLow, exec("low"))
+	normal := q.submit("group:1", PriorityNormal, exec("normal"))
+	high := q.submit("group:1", PriorityHigh, exec("high"))
+	if depth := metrics.SendQueueDepth.Get("low") - baseDepth; depth != 1 {
+		t.Errorf("Expected low lane depth 1, got %v", depth)
+	}
+	q.Start()
+
+	if id, err := low.Wait(); err != nil || id != 3 {
+		t.Fatalf("Expected low priority message sent last, got id=%d err=%v", id, err)
+	}
+	normal.Wait()
+	high.Wait()
+	if depth := metrics.SendQueueDepth.Get("low") - baseDepth; depth != 0 {
+		t.Errorf("Expected low lane depth 0 after sending, got %v", depth)
+	}
+
+	expected := []string{"high", "normal", "low"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 25*time.Millisecond {
+			t.Errorf("Expected interval between messages to the same target, got %s", gap)
+		}
+	}
+}
+
+// TestSendQueueAccountLimit 测试账号发送频率限制
+func TestSendQueueAccountLimit(t *testing.T) {
+	q := NewSendQueue(SendQueueConfig{AccountLimit: 2, AccountWindow: 50 * time.Millisecond}).Start()
+	defer q.Close()
+
+	start := time.Now()
+	var futures []*SendFuture
+	for i := int64(0); i < 3; i++ {
+		futures = append(futures, q.submit(groupTarget(i), PriorityNormal, func() (int64, error) { return 0, nil }))
+	}
+	for _, f := range futures {
+		f.Wait()
+	}
+
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("Expected third message to wait for account window, took %s", elapsed)
+	}
+}
@@ -7,30 +7,57 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/xiaoyi510/xbot/api"
 	"github.com/xiaoyi510/xbot/config"
+	"github.com/xiaoyi510/xbot/directory"
 	"github.com/xiaoyi510/xbot/driver"
 	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/history"
 	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/metrics"
 	"github.com/xiaoyi510/xbot/session"
 	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/trace"
+	"github.com/xiaoyi510/xbot/utils"
 
 	"github.com/redis/go-redis/v9"
 )
 
 // Config 机器人配置
 type Config struct {
-	Nickname      []string
-	SuperUsers    []int64
-	CommandPrefix string
-	Drivers       []driver.Driver
-	DriverConfigs []config.DriverConfig // 保存原始驱动器配置
-	Redis         *redis.Client
-	Storage       storage.Storage
+	Nickname         []string
+	SuperUsers       []int64
+	CommandPrefix    string
+	Drivers          []driver.Driver
+	DriverConfigs    []config.DriverConfig // 保存原始驱动器配置
+	Redis            *redis.Client
+	Storage          storage.Storage
+	APIPolicy        *api.Policy          // API 重试与熔断策略，为空时使用 api.DefaultPolicy()
+	SendQueue        *api.SendQueueConfig // 发送队列配置，为空时不排队，消息立即发送
+	LongMessage      LongMessageConfig    // ctx.Reply 的默认长消息处理方式
+	DirectoryTTL     time.Duration        // 群、成员、好友缓存有效期，为 0 时使用 directory.DefaultTTL
+	HistorySize      int                  // 每个会话保留的消息历史条数，为 0 时使用 history.DefaultSize
+	HistoryPersist   bool                 // 是否将消息历史持久化到 Storage
+	SessionPersist   bool                 // 是否将会话保存到 Storage，开启后 Session.Data 经过 JSON 编解码
+	AntiRecall       *AntiRecallConfig    // 防撤回配置，为空时只分发 RecalledMessageEvent
+	RequestPolicy    *RequestPolicyConfig // 加好友、加群请求自动处理策略，为空时不自动处理
+	DispatchMode     DispatchMode         // 匹配器执行方式，为空时使用 DispatchConcurrent
+	ConcurrentLevels bool                 // 顺序执行模式下不同优先级是否并发执行（同一优先级内始终依次执行）
+	MetricsAddr      string               // 指标服务监听地址，为空表示不启动
+	MetricsPath      string               // 指标路径，默认 /metrics
+	AdminAddr        string               // 管理后台监听地址，为空表示不启动
+	AdminToken       string               // 管理后台访问令牌
+
+	HeartbeatTimeout time.Duration // 心跳超时时间，为 0 时使用 3 倍心跳间隔
+	NotifyBotStatus  bool          // 机器人离线/恢复在线时是否私聊通知超级用户
+
+	NotifyErrors        bool          // 处理函数出错或 panic 时是否私聊通知超级用户
+	ErrorNotifyInterval time.Duration // 同一匹配器报告错误的最小间隔，为 0 时使用 DefaultErrorNotifyInterval
 }
 
 // Bot 机器人实例
@@ -41,6 +68,12 @@ type Bot struct {
 	engines        []*Engine
 	Storage        storage.Storage
 	SessionManager *session.Manager
+	Directory      *directory.Directory // 群、成员、好友信息缓存
+	History        *history.History     // 消息历史
+	Requests       *RequestManager      // 请求自动处理，未配置 RequestPolicy 时为 nil
+
+	lastErrorReports sync.Map     // 匹配器 ID -> 最近一次报告错误的时间
+	matcherCache     matcherCache // 所有引擎按优先级排序的匹配器缓存
 }
 
 // BotManager 机器人管理器
@@ -50,8 +83,16 @@ type BotManager struct {
 	drivers       []driver.Driver
 	driverConfigs []config.DriverConfig // 保存驱动器配置用于重试
 	storage       storage.Storage
+	apiPolicy     *api.Policy
+	metricsServer *metrics.Server
+	adminServer   *AdminServer
+	health        *healthMonitor
+	recentEvents  *utils.RingBuffer[EventRecord] // 最近事件，供管理后台查询
 }
 
+// recentEventsSize 保留的最近事件数量
+const recentEventsSize = 200
+
 // Run 运行机器人
 func Run(cfg *Config) (*BotManager, error) {
 	manager := &BotManager{
@@ -59,6 +100,22 @@ func Run(cfg *Config) (*BotManager, error) {
 		drivers:       cfg.Drivers,
 		driverConfigs: cfg.DriverConfigs,
 		storage:       cfg.Storage,
+		apiPolicy:     cfg.APIPolicy,
+		recentEvents:  utils.NewRingBuffer[EventRecord](recentEventsSize),
+	}
+
+	// 配置有误时在启动任何组件之前返回错误
+	if cfg.RequestPolicy != nil {
+		if err := cfg.RequestPolicy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AdminAddr != "" {
+		adminServer, err := NewAdminServer(manager, cfg.AdminAddr, cfg.AdminToken)
+		if err != nil {
+			return nil, err
+		}
+		manager.adminServer = adminServer
 	}
 
 	// 如果没有提供存储，使用默认的内存存储
@@ -66,6 +123,30 @@ func Run(cfg *Config) (*BotManager, error) {
 		manager.storage = storage.NewMemoryStorage()
 	}
 
+	// 所有 Bot 共用同一个 API 策略，熔断状态也随之共享
+	if manager.apiPolicy == nil {
+		manager.apiPolicy = api.DefaultPolicy()
+	}
+
+	// 根据心跳跟踪机器人健康状态
+	manager.health = newHealthMonitor(cfg.HeartbeatTimeout, manager.onBotStatusChange)
+	manager.health.start()
+
+	// 注册驱动器连接状态指标并启动指标服务（同时提供健康检查探针）
+	manager.registerDriverMetrics()
+	manager.registerHealthMetrics()
+	if cfg.MetricsAddr != "" {
+		manager.metricsServer = metrics.NewServer(cfg.MetricsAddr, cfg.MetricsPath)
+		manager.metricsServer.Handle("/healthz", manager.HealthHandler())
+		manager.metricsServer.Handle("/readyz", manager.ReadyHandler())
+		manager.metricsServer.Start()
+	}
+
+	// 启动管理后台
+	if manager.adminServer != nil {
+		manager.adminServer.Start()
+	}
+
 	// 设置事件处理器并连接驱动器
 	for i, d := range manager.drivers {
 		d.SetEventHandler(manager.handleEvent)
@@ -148,6 +229,27 @@ func (bm *BotManager) connectWithRetry(d driver.Driver, driverIndex int, drvCfg
 	return fmt.Errorf("连接失败，已重试 %d 次: %w", maxRetries, lastErr)
 }
 
+// registerDriverMetrics 注册驱动器连接状态指标
+func (bm *BotManager) registerDriverMetrics() {
+	metrics.DefaultRegistry.Unregister("xbot_driver_connected")
+	metrics.DefaultRegistry.MustRegister(metrics.NewGaugeFunc("xbot_driver_connected",
+		"驱动器连接状态（1 已连接，0 未连接）", []string{"driver", "type"},
+		func(observe func(float64, ...string)) {
+			for i, d := range bm.drivers {
+				driverType := fmt.Sprintf("%T", d)
+				if i < len(bm.driverConfigs) {
+					driverType = bm.driverConfigs[i].Type
+				}
+
+				value := 0.0
+				if d.IsConnected() {
+					value = 1
+				}
+				observe(value, strconv.Itoa(i), driverType)
+			}
+		}))
+}
+
 // RunAndListen 运行并阻塞
 func RunAndListen(cfg *Config) error {
 	manager, err := Run(cfg)
@@ -181,6 +283,38 @@ func (bm *BotManager) Stop() {
 		}
 	}
 
+	// 关闭发送队列，写入尚未保存的消息历史
+	for _, bot := range bm.GetAllBots() {
+		if bot.API != nil && bot.API.SendQueue() != nil {
+			bot.API.SendQueue().Close()
+		}
+		if bot.History != nil {
+			bot.History.Flush()
+		}
+		if bot.SessionManager != nil {
+			bot.SessionManager.Close()
+		}
+	}
+
+	// 停止健康监控
+	if bm.health != nil {
+		bm.health.close()
+	}
+
+	// 关闭指标服务
+	if bm.metricsServer != nil {
+		if err := bm.metricsServer.Close(); err != nil {
+			logger.Error("关闭指标服务失败", "error", err)
+		}
+	}
+
+	// 关闭管理后台
+	if bm.adminServer != nil {
+		if err := bm.adminServer.Close(); err != nil {
+			logger.Error("关闭管理后台失败", "error", err)
+		}
+	}
+
 	// 关闭存储
 	if bm.storage != nil {
 		if err := bm.storage.Close(); err != nil {
@@ -214,6 +348,11 @@ func (bm *BotManager) GetAllBots() []*Bot {
 // handleEvent 处理事件
 func (bm *BotManager) handleEvent(evt event.Event) {
 	selfID := evt.GetSelfID()
+	metrics.EventsReceived.Inc(evt.GetPostType())
+	bm.recentEvents.Push(newEventRecord(evt))
+
+	// 事件分发完成后结束根跨度，处理函数的跨度会各自独立结束
+	defer event.SpanOf(evt).End()
 
 	// 获取或创建 Bot 实例
 	bot, ok := bm.GetBot(selfID)
@@ -226,6 +365,37 @@ func (bm *BotManager) handleEvent(evt event.Event) {
 	// 记录消息日志（只记录一次）
 	logMessageEvent(evt, bot)
 
+	// 记录心跳
+	if hb, ok := evt.(*event.HeartbeatMetaEvent); ok && bm.health != nil {
+		bm.health.recordHeartbeat(hb, time.Now())
+	}
+
+	// OneBot 实现上报的离线通知（NapCat bot_offline）立即更新健康状态；
+	// 心跳超时产生的离线事件此时已是不健康状态，不会重复通知
+	if status, ok := evt.(*event.BotStatusEvent); ok && !status.Online && bm.health != nil {
+		if bm.health.recordOffline(status.SelfID, status.Reason) && bm.config.NotifyBotStatus {
+			go bm.notifySuperUsers(status.SelfID, false, status.Reason)
+		}
+	}
+
+	// 更新群、成员、好友缓存
+	if bot.Directory != nil {
+		bot.Directory.HandleEvent(evt)
+	}
+
+	// 记录消息历史
+	if bot.History != nil {
+		bot.History.RecordEvent(evt)
+	}
+
+	// 防撤回：根据消息历史生成附带原消息的撤回事件
+	bm.captureRecall(bot, evt)
+
+	// 按策略处理加好友、加群请求及审核人的回复
+	if bot.Requests != nil {
+		bot.Requests.HandleEvent(evt)
+	}
+
 	// 通知会话管理器
 	if msgEvt, ok := evt.(*event.PrivateMessageEvent); ok {
 		bot.SessionManager.NotifyWaitSession(msgEvt.UserID, 0, NewContext(evt, bot))
@@ -233,10 +403,8 @@ func (bm *BotManager) handleEvent(evt event.Event) {
 		bot.SessionManager.NotifyWaitSession(msgEvt.UserID, msgEvt.GroupID, NewContext(evt, bot))
 	}
 
-	// 分发到所有引擎
-	for _, engine := range bot.engines {
-		go engine.HandleEvent(evt)
-	}
+	// 所有引擎共享同一个 Context，按全局优先级分发
+	go dispatch(NewContext(evt, bot), bot.engines, &bot.matcherCache)
 }
 
 // createBot 创建 Bot 实例
@@ -244,11 +412,22 @@ func (bm *BotManager) createBot(selfID int64) *Bot {
 	// 为每个 Bot 创建 API 客户端
 	var apiClient *api.Client
 	if len(bm.drivers) > 0 {
-		apiClient = api.NewClient(bm.drivers[0])
+		apiClient = api.NewClient(bm.drivers[0]).SetPolicy(bm.apiPolicy)
+
+		// 每个账号使用独立的发送队列
+		if bm.config.SendQueue != nil {
+			apiClient.SetSendQueue(api.NewSendQueue(*bm.config.SendQueue).Start())
+		}
 	}
 
 	// 创建会话管理器
-	sessionStore := session.NewMemoryStore()
+	// 默认保存在内存中；开启 SessionPersist 时保存到存储，存储为 LevelDB 时对话状态在重启后可恢复
+	var sessionStore session.Store
+	if bm.config.SessionPersist && bm.storage != nil {
+		sessionStore = session.NewStorageStore(bm.storage, fmt.Sprintf("session:%d:", selfID))
+	} else {
+		sessionStore = session.NewMemoryStore()
+	}
 	sessionManager := session.NewManager(sessionStore, 5*time.Minute)
 
 	bot := &Bot{
@@ -258,6 +437,32 @@ func (bm *BotManager) createBot(selfID int64) *Bot {
 		engines:        GetEngines(),
 		Storage:        bm.storage,
 		SessionManager: sessionManager,
+		History:        history.New(bm.config.HistorySize),
+	}
+	if bm.config.HistoryPersist {
+		bot.History.SetStorage(bm.storage, fmt.Sprintf("history:%d:", selfID))
+	}
+	if apiClient != nil {
+		bot.Directory = directory.New(apiClient, bm.config.DirectoryTTL)
+
+		// 记录机器人自己发送的消息
+		apiClient.OnMessageSent(func(sent api.SentMessage) {
+			nickname := ""
+			if len(bm.config.Nickname) > 0 {
+				nickname = bm.config.Nickname[0]
+			}
+			bot.History.RecordSent(selfID, nickname, sent)
+		})
+	}
+
+	if bm.config.RequestPolicy != nil {
+		// 规则已在 Run 中校验，这里不会失败
+		requests, err := NewRequestManager(bot, bm.config.RequestPolicy)
+		if err != nil {
+			logger.Error("创建请求管理器失败", "selfID", selfID, "error", err)
+		} else {
+			bot.Requests = requests
+		}
 	}
 
 	// 设置引擎的 Bot 引用
@@ -265,6 +470,9 @@ func (bm *BotManager) createBot(selfID int64) *Bot {
 		engine.SetBot(bot)
 	}
 
+	// 恢复重启前未结束的对话的提醒和超时
+	restoreDialogs(bot)
+
 	return bot
 }
 
@@ -306,14 +514,89 @@ func LoadConfigFile(path string) (*Config, error) {
 		CommandPrefix: cfg.Bot.CommandPrefix,
 	}
 
-	// 设置日志级别
-	logger.SetLevel(logger.ParseLevel(cfg.Log.Level))
+	// 指标服务
+	if cfg.Metrics.Enabled {
+		botCfg.MetricsAddr = cfg.Metrics.Addr
+		botCfg.MetricsPath = cfg.Metrics.Path
+	}
 
-	// 如果配置了日志文件，同时输出到文件
-	if cfg.Log.File != "" {
-		file := logger.MustCreateFile(cfg.Log.File)
-		multiWriter := logger.NewMultiWriter(os.Stdout, file)
-		logger.SetDefault(logger.NewDefaultLogger(multiWriter, logger.ParseLevel(cfg.Log.Level)))
+	// API 策略
+	botCfg.APIPolicy = newAPIPolicyFromConfig(cfg)
+
+	// 长消息处理
+	botCfg.LongMessage = LongMessageConfig{
+		Mode:      LongMessageMode(cfg.LongMessage.Mode),
+		MaxLength: cfg.LongMessage.MaxLength,
+		MaxSplit:  cfg.LongMessage.MaxSplit,
+	}
+
+	// 匹配器执行方式
+	botCfg.DispatchMode = DispatchMode(cfg.Dispatch.Mode)
+	botCfg.ConcurrentLevels = cfg.Dispatch.ConcurrentLevels
+
+	// 群、成员、好友缓存
+	botCfg.DirectoryTTL = time.Duration(cfg.Directory.TTL) * time.Second
+
+	// 消息历史
+	botCfg.HistorySize = cfg.History.Size
+	botCfg.HistoryPersist = cfg.History.Persist
+
+	// 会话
+	botCfg.SessionPersist = cfg.Session.Persist
+
+	// 防撤回
+	if cfg.AntiRecall.Enabled {
+		botCfg.AntiRecall = &AntiRecallConfig{
+			AuditGroups: cfg.AntiRecall.AuditGroups,
+			IgnoreSelf:  cfg.AntiRecall.IgnoreSelf,
+		}
+	}
+
+	// 请求自动处理
+	if cfg.RequestPolicy.Enabled {
+		policy, err := newRequestPolicyFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		botCfg.RequestPolicy = policy
+	}
+
+	// 发送队列
+	if cfg.SendQueue.Enabled {
+		botCfg.SendQueue = &api.SendQueueConfig{
+			TargetInterval: time.Duration(cfg.SendQueue.TargetInterval) * time.Millisecond,
+			AccountLimit:   cfg.SendQueue.AccountLimit,
+			AccountWindow:  time.Duration(cfg.SendQueue.AccountWindow) * time.Second,
+			Jitter:         time.Duration(cfg.SendQueue.Jitter) * time.Millisecond,
+			Size:           cfg.SendQueue.Size,
+		}
+	}
+
+	// 管理后台
+	if cfg.Admin.Enabled {
+		botCfg.AdminAddr = cfg.Admin.Addr
+		botCfg.AdminToken = cfg.Admin.Token
+	}
+
+	// 健康检查
+	botCfg.HeartbeatTimeout = time.Duration(cfg.Health.HeartbeatTimeout) * time.Second
+	botCfg.NotifyBotStatus = cfg.Health.NotifySuperUsers
+
+	// 错误报告
+	botCfg.NotifyErrors = cfg.Errors.NotifySuperUsers
+	botCfg.ErrorNotifyInterval = time.Duration(cfg.Errors.NotifyInterval) * time.Second
+
+	// 设置日志输出（控制台 + 可选的轮转文件）
+	logger.SetDefault(newLoggerFromConfig(cfg))
+
+	// 链路追踪
+	if cfg.Trace.Enabled {
+		switch cfg.Trace.Exporter {
+		case "memory":
+			trace.Enable(trace.NewMemoryExporter(cfg.Trace.MemorySize))
+		default:
+			trace.Enable(trace.NewStdoutExporter())
+		}
 	}
 
 	// 创建存储
@@ -412,6 +695,100 @@ func LoadConfigFile(path string) (*Config, error) {
 	return botCfg, nil
 }
 
+// newLoggerFromConfig 根据配置创建日志记录器
+// 控制台与文件可以分别使用不同的日志格式
+func newLoggerFromConfig(cfg *config.BotConfig) logger.Logger {
+	level := logger.ParseLevel(cfg.Log.Level)
+
+	sinks := []logger.Sink{{
+		Writer:  os.Stdout,
+		Encoder: logger.NewEncoder(logger.ParseFormat(cfg.Log.ConsoleFormat), true),
+	}}
+
+	// 如果配置了日志文件，同时输出到文件
+	if cfg.Log.File != "" {
+		file := logger.MustCreateRotateWriter(logger.RotateConfig{
+			Filename:   cfg.Log.File,
+			MaxSize:    cfg.Log.Rotate.MaxSize,
+			Interval:   time.Duration(cfg.Log.Rotate.Interval) * time.Hour,
+			MaxBackups: cfg.Log.Rotate.MaxBackups,
+			Compress:   cfg.Log.Rotate.Compress,
+		})
+		sinks = append(sinks, logger.Sink{
+			Writer:  file,
+			Encoder: logger.NewEncoder(logger.ParseFormat(cfg.Log.FileFormat), false),
+		})
+	}
+
+	l := logger.NewSinkLogger(level, sinks...)
+	l.SetModuleLevels(logger.ParseModuleLevels(cfg.Log.Levels))
+	return l
+}
+
+// newAPIPolicyFromConfig 根据配置创建 API 策略
+// 配置中的负数表示关闭对应功能
+func newAPIPolicyFromConfig(cfg *config.BotConfig) *api.Policy {
+	waitReconnect := time.Duration(max(cfg.API.WaitReconnect, 0)) * time.Second
+
+	policy := api.NewPolicy().
+		SetReadPolicy(api.RetryPolicy{
+			MaxRetries:     max(cfg.API.Retry.MaxRetries, 0),
+			InitialBackoff: time.Duration(cfg.API.Retry.InitialBackoff) * time.Millisecond,
+			MaxBackoff:     time.Duration(cfg.API.Retry.MaxBackoff) * time.Millisecond,
+			WaitReconnect:  waitReconnect,
+		}).
+		SetWritePolicy(api.RetryPolicy{
+			WaitReconnect: waitReconnect,
+		})
+
+	for action, a := range cfg.API.Actions {
+		policy.SetActionPolicy(action, api.RetryPolicy{
+			MaxRetries:     a.MaxRetries,
+			InitialBackoff: time.Duration(a.InitialBackoff) * time.Millisecond,
+			MaxBackoff:     time.Duration(a.MaxBackoff) * time.Millisecond,
+			WaitReconnect:  time.Duration(a.WaitReconnect) * time.Second,
+		})
+	}
+
+	if cfg.API.Breaker.FailureThreshold > 0 {
+		policy.SetBreaker(api.NewCircuitBreaker(cfg.API.Breaker.FailureThreshold,
+			time.Duration(cfg.API.Breaker.OpenTimeout)*time.Second))
+	}
+
+	return policy
+}
+
+// newRequestPolicyFromConfig 根据配置创建请求自动处理策略，规则中的正则表达式无效时返回错误
+func newRequestPolicyFromConfig(cfg *config.BotConfig) (*RequestPolicyConfig, error) {
+	rule := func(r config.RequestRuleConfig) RequestRule {
+		return RequestRule{
+			Answer:       r.Answer,
+			MinLevel:     r.MinLevel,
+			Fallback:     RequestAction(r.Fallback),
+			RejectReason: r.RejectReason,
+			Admins:       r.Admins,
+			NotifyGroup:  r.NotifyGroup,
+		}
+	}
+
+	policy := &RequestPolicyConfig{
+		Friend:                rule(cfg.RequestPolicy.Friend),
+		Group:                 rule(cfg.RequestPolicy.Group),
+		Groups:                make(map[int64]RequestRule, len(cfg.RequestPolicy.Groups)),
+		AcceptSuperUserInvite: cfg.RequestPolicy.AcceptSuperUserInvite,
+		InviteAction:          RequestAction(cfg.RequestPolicy.InviteAction),
+		PendingTimeout:        time.Duration(cfg.RequestPolicy.PendingTimeout) * time.Second,
+		TimeoutAction:         RequestAction(cfg.RequestPolicy.TimeoutAction),
+	}
+	for groupID, r := range cfg.RequestPolicy.Groups {
+		policy.Groups[groupID] = rule(r)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
 // GetStorage 获取插件专用存储
 func GetStorage(pluginName string) storage.Storage {
 	// 创建插件数据目录
@@ -454,6 +831,8 @@ func logMessageEvent(evt event.Event, bot *Bot) {
 		)
 	case *event.HeartbeatMetaEvent:
 		logger.Info("收到心跳事件", "心跳类型", e.MetaEventType, "心跳数据", e.Status)
+	case *event.BotStatusEvent:
+		logger.Info("机器人在线状态变化", "在线", e.Online, "原因", e.Reason)
 	case *event.LifecycleMetaEvent:
 		logger.Info("收到生命周期事件", "生命周期类型", e.SubType, "生命周期数据", e.MetaEventType)
 	default:
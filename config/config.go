@@ -24,10 +24,131 @@ type BotConfig struct {
 	} `yaml:"redis"`
 
 	Log struct {
-		Level string `yaml:"level"`
-		File  string `yaml:"file"`
+		Level         string `yaml:"level"`
+		File          string `yaml:"file"`
+		ConsoleFormat string `yaml:"console_format"` // 控制台日志格式：text 或 json
+		FileFormat    string `yaml:"file_format"`    // 文件日志格式：text 或 json
+
+		// 按模块设置日志级别，例如 {driver: debug, plugin.weather: warn}
+		Levels map[string]string `yaml:"levels"`
+
+		// 日志文件轮转配置
+		Rotate struct {
+			MaxSize    int  `yaml:"max_size"`    // 单个文件最大大小（MB），0 表示不按大小轮转
+			Interval   int  `yaml:"interval"`    // 按时间轮转间隔（小时），0 表示不按时间轮转
+			MaxBackups int  `yaml:"max_backups"` // 保留的历史文件数量，0 表示全部保留
+			Compress   bool `yaml:"compress"`    // 是否 gzip 压缩历史文件
+		} `yaml:"rotate"`
 	} `yaml:"log"`
 
+	// API 调用的重试、等待重连与熔断策略
+	API struct {
+		WaitReconnect int `yaml:"wait_reconnect"` // 未连接时等待重连的最长时间（秒），默认 10，-1 表示不等待
+
+		// 查询类 API（get_、can_ 开头）超时后的重试策略
+		Retry struct {
+			MaxRetries     int `yaml:"max_retries"`     // 最大重试次数，默认 2，-1 表示不重试
+			InitialBackoff int `yaml:"initial_backoff"` // 首次重试等待时间（毫秒），默认 500
+			MaxBackoff     int `yaml:"max_backoff"`     // 最大重试等待时间（毫秒），默认 5000
+		} `yaml:"retry"`
+
+		// 熔断器：连续传输失败达到阈值后，在 open_timeout 内直接返回错误
+		Breaker struct {
+			FailureThreshold int `yaml:"failure_threshold"` // 连续失败阈值，默认 5，-1 表示不熔断
+			OpenTimeout      int `yaml:"open_timeout"`      // 熔断持续时间（秒），默认 30
+		} `yaml:"breaker"`
+
+		// 按 API 名称单独配置，例如 {send_group_msg: {max_retries: 1}}
+		Actions map[string]APIActionConfig `yaml:"actions"`
+	} `yaml:"api"`
+
+	// 长消息处理：ctx.Reply 发送超长纯文本时的默认行为
+	LongMessage struct {
+		Mode      string `yaml:"mode"`       // none、split、forward 或 auto，默认 none
+		MaxLength int    `yaml:"max_length"` // 单条消息最大字符数，默认 1000
+		MaxSplit  int    `yaml:"max_split"`  // auto 模式下最多切分的条数，超过时转为合并转发，默认 3
+	} `yaml:"long_message"`
+
+	// 匹配器执行方式
+	Dispatch struct {
+		Mode             string `yaml:"mode"`              // concurrent 或 sequential，默认 concurrent
+		ConcurrentLevels bool   `yaml:"concurrent_levels"` // sequential 模式下不同优先级并发执行，同一优先级内仍依次执行
+	} `yaml:"dispatch"`
+
+	// 群、成员、好友缓存：首次访问时加载，根据通知事件增量更新
+	Directory struct {
+		TTL int `yaml:"ttl"` // 缓存有效期（秒），默认 600
+	} `yaml:"directory"`
+
+	// 消息历史：记录每个会话最近的消息，用于解析引用回复等
+	History struct {
+		Size    int  `yaml:"size"`    // 每个会话保留的消息条数，默认 100
+		Persist bool `yaml:"persist"` // 是否持久化到存储，重启后可恢复
+	} `yaml:"history"`
+
+	// 会话：多轮对话和对话状态机的进度
+	Session struct {
+		Persist bool `yaml:"persist"` // 是否保存到存储，重启后对话可继续；Data 中的值经过 JSON 编解码
+	} `yaml:"session"`
+
+	// 防撤回：群消息被撤回时将原消息转发到审计群
+	AntiRecall struct {
+		Enabled     bool    `yaml:"enabled"`
+		AuditGroups []int64 `yaml:"audit_groups"` // 审计群号列表
+		IgnoreSelf  bool    `yaml:"ignore_self"`  // 不转发机器人自己撤回的消息
+	} `yaml:"anti_recall"`
+
+	// 请求自动处理：按规则同意、拒绝或转交审核人处理加好友、加群请求
+	RequestPolicy struct {
+		Enabled               bool                        `yaml:"enabled"`
+		Friend                RequestRuleConfig           `yaml:"friend"`                  // 加好友请求规则
+		Group                 RequestRuleConfig           `yaml:"group"`                   // 加群请求的默认规则
+		Groups                map[int64]RequestRuleConfig `yaml:"groups"`                  // 按群号单独配置的加群请求规则
+		AcceptSuperUserInvite bool                        `yaml:"accept_superuser_invite"` // 超级用户邀请机器人入群时自动同意
+		InviteAction          string                      `yaml:"invite_action"`           // 其他人邀请入群时的处理方式：approve、reject、manual 或 ignore，默认 ignore
+		PendingTimeout        int                         `yaml:"pending_timeout"`         // 待审核请求超时时间（秒），默认 86400
+		TimeoutAction         string                      `yaml:"timeout_action"`          // 超时后的处理方式：approve、reject 或 ignore，默认 reject
+	} `yaml:"request_policy"`
+
+	// 发送队列：按目标和账号限制发送频率，降低风控概率
+	SendQueue struct {
+		Enabled        bool `yaml:"enabled"`
+		TargetInterval int  `yaml:"target_interval"` // 同一群/私聊两条消息的最小间隔（毫秒），默认 1000
+		AccountLimit   int  `yaml:"account_limit"`   // 每个账号在 account_window 内最多发送的消息数，默认 20
+		AccountWindow  int  `yaml:"account_window"`  // 账号限流时间窗口（秒），默认 60
+		Jitter         int  `yaml:"jitter"`          // 发送前附加的随机延迟上限（毫秒），默认 0
+		Size           int  `yaml:"size"`            // 每个优先级队列的最大长度，默认 1000
+	} `yaml:"send_queue"`
+
+	Metrics struct {
+		Enabled bool   `yaml:"enabled"`
+		Addr    string `yaml:"addr"` // 监听地址，默认 :9090
+		Path    string `yaml:"path"` // 指标路径，默认 /metrics
+	} `yaml:"metrics"`
+
+	Admin struct {
+		Enabled bool   `yaml:"enabled"`
+		Addr    string `yaml:"addr"`  // 监听地址，默认 127.0.0.1:8081
+		Token   string `yaml:"token"` // 访问令牌，为空时不鉴权且 addr 只能是本机回环地址
+	} `yaml:"admin"`
+
+	Health struct {
+		HeartbeatTimeout int  `yaml:"heartbeat_timeout"`  // 心跳超时时间（秒），0 表示使用 3 倍心跳间隔
+		NotifySuperUsers bool `yaml:"notify_super_users"` // 机器人离线/恢复在线时是否私聊通知超级用户
+	} `yaml:"health"`
+
+	// 错误报告：处理函数返回错误或 panic 时私聊通知超级用户
+	Errors struct {
+		NotifySuperUsers bool `yaml:"notify_super_users"`
+		NotifyInterval   int  `yaml:"notify_interval"` // 同一匹配器报告错误的最小间隔（秒），默认 60
+	} `yaml:"errors"`
+
+	Trace struct {
+		Enabled    bool   `yaml:"enabled"`
+		Exporter   string `yaml:"exporter"`    // stdout 或 memory，默认 stdout
+		MemorySize int    `yaml:"memory_size"` // memory 导出器保留的跨度数量，默认 1000
+	} `yaml:"trace"`
+
 	Storage struct {
 		Type string `yaml:"type"` // leveldb or memory
 		Path string `yaml:"path"`
@@ -56,6 +177,25 @@ type DriverConfig struct {
 	Timeout int `yaml:"timeout"` // API 调用超时（秒）
 }
 
+// APIActionConfig 单个 API 的重试策略
+// 注意：对发送消息等非幂等 API 配置重试，可能在超时后导致重复发送
+type APIActionConfig struct {
+	MaxRetries     int `yaml:"max_retries"`     // 超时后最大重试次数
+	InitialBackoff int `yaml:"initial_backoff"` // 首次重试等待时间（毫秒）
+	MaxBackoff     int `yaml:"max_backoff"`     // 最大重试等待时间（毫秒）
+	WaitReconnect  int `yaml:"wait_reconnect"`  // 未连接时等待重连的最长时间（秒）
+}
+
+// RequestRuleConfig 请求处理规则配置
+type RequestRuleConfig struct {
+	Answer       string  `yaml:"answer"`        // 验证信息需匹配的正则表达式
+	MinLevel     int     `yaml:"min_level"`     // 申请人最低 QQ 等级
+	Fallback     string  `yaml:"fallback"`      // 不满足条件时的处理方式：approve、reject、manual 或 ignore，默认 manual
+	RejectReason string  `yaml:"reject_reason"` // 拒绝理由
+	Admins       []int64 `yaml:"admins"`        // 审核人，为空时使用超级用户
+	NotifyGroup  int64   `yaml:"notify_group"`  // 审核通知群，为 0 时私聊通知审核人
+}
+
 // LoadConfig 从文件加载配置
 func LoadConfig(path string) (*BotConfig, error) {
 	data, err := os.ReadFile(path)
@@ -85,6 +225,109 @@ func setDefaults(config *BotConfig) {
 	if config.Log.Level == "" {
 		config.Log.Level = "info"
 	}
+	if config.Log.ConsoleFormat == "" {
+		config.Log.ConsoleFormat = "text"
+	}
+	if config.Log.FileFormat == "" {
+		config.Log.FileFormat = "text"
+	}
+
+	// API 策略默认值
+	if config.API.WaitReconnect == 0 {
+		config.API.WaitReconnect = 10
+	}
+	if config.API.Retry.MaxRetries == 0 {
+		config.API.Retry.MaxRetries = 2
+	}
+	if config.API.Retry.InitialBackoff == 0 {
+		config.API.Retry.InitialBackoff = 500
+	}
+	if config.API.Retry.MaxBackoff == 0 {
+		config.API.Retry.MaxBackoff = 5000
+	}
+	if config.API.Breaker.FailureThreshold == 0 {
+		config.API.Breaker.FailureThreshold = 5
+	}
+	if config.API.Breaker.OpenTimeout == 0 {
+		config.API.Breaker.OpenTimeout = 30
+	}
+
+	// 长消息默认值
+	if config.LongMessage.Mode == "" {
+		config.LongMessage.Mode = "none"
+	}
+	if config.LongMessage.MaxLength == 0 {
+		config.LongMessage.MaxLength = 1000
+	}
+	if config.LongMessage.MaxSplit == 0 {
+		config.LongMessage.MaxSplit = 3
+	}
+
+	// 匹配器执行方式默认值
+	if config.Dispatch.Mode == "" {
+		config.Dispatch.Mode = "concurrent"
+	}
+
+	// 缓存默认值
+	if config.Directory.TTL == 0 {
+		config.Directory.TTL = 600
+	}
+
+	// 消息历史默认值
+	if config.History.Size == 0 {
+		config.History.Size = 100
+	}
+
+	// 错误报告默认值
+	if config.Errors.NotifyInterval == 0 {
+		config.Errors.NotifyInterval = 60
+	}
+
+	// 请求自动处理默认值
+	if config.RequestPolicy.InviteAction == "" {
+		config.RequestPolicy.InviteAction = "ignore"
+	}
+	if config.RequestPolicy.PendingTimeout == 0 {
+		config.RequestPolicy.PendingTimeout = 86400
+	}
+	if config.RequestPolicy.TimeoutAction == "" {
+		config.RequestPolicy.TimeoutAction = "reject"
+	}
+
+	// 发送队列默认值
+	if config.SendQueue.TargetInterval == 0 {
+		config.SendQueue.TargetInterval = 1000
+	}
+	if config.SendQueue.AccountLimit == 0 {
+		config.SendQueue.AccountLimit = 20
+	}
+	if config.SendQueue.AccountWindow == 0 {
+		config.SendQueue.AccountWindow = 60
+	}
+	if config.SendQueue.Size == 0 {
+		config.SendQueue.Size = 1000
+	}
+
+	// 指标默认值
+	if config.Metrics.Addr == "" {
+		config.Metrics.Addr = ":9090"
+	}
+	if config.Metrics.Path == "" {
+		config.Metrics.Path = "/metrics"
+	}
+
+	// 管理后台默认值
+	if config.Admin.Addr == "" {
+		config.Admin.Addr = "127.0.0.1:8081"
+	}
+
+	// 链路追踪默认值
+	if config.Trace.Exporter == "" {
+		config.Trace.Exporter = "stdout"
+	}
+	if config.Trace.MemorySize == 0 {
+		config.Trace.MemorySize = 1000
+	}
 
 	// 存储默认值
 	if config.Storage.Type == "" {
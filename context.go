@@ -3,13 +3,21 @@ package xbot
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/xiaoyi510/xbot/api"
 	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/history"
 	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/message"
 	"github.com/xiaoyi510/xbot/session"
 	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/trace"
+	"github.com/xiaoyi510/xbot/types"
 )
 
 // RegexMatch 正则匹配结果
@@ -23,35 +31,71 @@ type RegexMatch struct {
 }
 
 // Context 上下文
+// 同一事件的所有匹配器共享状态（State、Abort、Next），
+// 每个处理函数拿到的是各自的 Context 副本，RegexResult 等匹配结果互不影响
 type Context struct {
 	Event   event.Event
 	Bot     *Bot
-	State   map[string]interface{}
+	State   map[string]interface{} // 事件状态，处理函数可能并发执行，请通过 Set/Get 访问
 	Logger  logger.Logger
 	Storage storage.Storage
 	Session *session.Manager
 
+	// Span 事件的追踪跨度，未启用追踪时为 nil
+	Span *trace.Span
+
 	// RegexResult 正则匹配结果
 	RegexResult *RegexMatch
 
-	matched        bool
-	aborted        bool // 是否中止后续匹配器
-	shouldContinue bool // 是否显式调用Next()继续
+	shared *eventState
+}
+
+// eventState 同一事件的所有 Context 副本共享的状态
+type eventState struct {
+	mu             sync.RWMutex // 保护 Context.State
+	matched        atomic.Bool  // 是否有匹配器匹配成功
+	aborted        atomic.Bool  // 是否中止后续匹配器
+	shouldContinue atomic.Bool  // 是否显式调用Next()继续
+
+	dialogOnce sync.Once      // 只读取一次对话进度
+	dialog     *DialogSession // 事件到达时的对话进度
 }
 
 // NewContext 创建上下文
 func NewContext(evt event.Event, bot *Bot) *Context {
-	return &Context{
-		Event:          evt,
-		Bot:            bot,
-		State:          make(map[string]interface{}),
-		Logger:         logger.GetDefault().WithField("selfID", evt.GetSelfID()),
-		Storage:        bot.Storage,
-		Session:        bot.SessionManager,
-		matched:        false,
-		aborted:        false,
-		shouldContinue: true, // 默认继续执行后续匹配器
+	span := event.SpanOf(evt)
+
+	log := logger.GetDefault().WithField("selfID", evt.GetSelfID())
+	if span != nil {
+		log = log.WithField("trace_id", span.TraceID().String())
 	}
+
+	ctx := &Context{
+		Event:   evt,
+		Bot:     bot,
+		State:   make(map[string]interface{}),
+		Logger:  log,
+		Storage: bot.Storage,
+		Session: bot.SessionManager,
+		Span:    span,
+		shared:  &eventState{},
+	}
+	ctx.shared.shouldContinue.Store(true) // 默认继续执行后续匹配器
+	return ctx
+}
+
+// forMatcher 为匹配成功的处理函数创建 Context 副本，副本带有该匹配器的正则匹配结果，
+// 后续匹配器的匹配不会修改处理函数正在使用的副本
+func (ctx *Context) forMatcher(regex *RegexMatch) *Context {
+	c := *ctx
+	c.RegexResult = regex
+	return &c
+}
+
+// API 获取 API 客户端
+// 启用追踪时，通过该客户端发起的调用会记录为当前事件的子跨度
+func (ctx *Context) API() *api.Client {
+	return ctx.Bot.API.WithTrace(ctx.Span)
 }
 
 // GetUserID 获取用户 ID
@@ -65,6 +109,36 @@ func (ctx *Context) GetUserID() int64 {
 		return evt.UserID
 	case *event.GroupRequestEvent:
 		return evt.UserID
+	case *event.RecalledMessageEvent:
+		return evt.UserID
+	case *event.GroupIncreaseNoticeEvent:
+		return evt.UserID
+	case *event.GroupDecreaseNoticeEvent:
+		return evt.UserID
+	case *event.GroupRecallNoticeEvent:
+		return evt.UserID
+	case *event.NotifyNoticeEvent:
+		return evt.UserID
+	case *event.GroupCardNoticeEvent:
+		return evt.UserID
+	case *event.OfflineFileNoticeEvent:
+		return evt.UserID
+	case *event.EssenceNoticeEvent:
+		return evt.SenderID
+	case *event.GroupMsgEmojiLikeNoticeEvent:
+		return evt.UserID
+	case *event.InputStatusNoticeEvent:
+		return evt.UserID
+	case *event.GroupAdminNoticeEvent:
+		return evt.UserID
+	case *event.GroupBanNoticeEvent:
+		return evt.UserID
+	case *event.GroupUploadNoticeEvent:
+		return evt.UserID
+	case *event.FriendAddNoticeEvent:
+		return evt.UserID
+	case *event.FriendRecallNoticeEvent:
+		return evt.UserID
 	default:
 		return 0
 	}
@@ -77,6 +151,30 @@ func (ctx *Context) GetGroupID() int64 {
 		return evt.GroupID
 	case *event.GroupRequestEvent:
 		return evt.GroupID
+	case *event.RecalledMessageEvent:
+		return evt.GroupID
+	case *event.GroupIncreaseNoticeEvent:
+		return evt.GroupID
+	case *event.GroupDecreaseNoticeEvent:
+		return evt.GroupID
+	case *event.GroupRecallNoticeEvent:
+		return evt.GroupID
+	case *event.NotifyNoticeEvent:
+		return evt.GroupID
+	case *event.GroupCardNoticeEvent:
+		return evt.GroupID
+	case *event.EssenceNoticeEvent:
+		return evt.GroupID
+	case *event.GroupMsgEmojiLikeNoticeEvent:
+		return evt.GroupID
+	case *event.InputStatusNoticeEvent:
+		return evt.GroupID
+	case *event.GroupAdminNoticeEvent:
+		return evt.GroupID
+	case *event.GroupBanNoticeEvent:
+		return evt.GroupID
+	case *event.GroupUploadNoticeEvent:
+		return evt.GroupID
 	default:
 		return 0
 	}
@@ -184,41 +282,160 @@ func (ctx *Context) GetAtUsers() []int64 {
 	return users
 }
 
+// GetReplyID 获取引用回复的消息 ID，没有引用时返回 0
+func (ctx *Context) GetReplyID() int64 {
+	msg := ctx.GetMessage()
+	if msg == nil {
+		return 0
+	}
+
+	seg, ok := msg.GetFirstSegmentByType("reply")
+	if !ok {
+		return 0
+	}
+	switch id := seg.Data["id"].(type) {
+	case string:
+		messageID, _ := strconv.ParseInt(id, 10, 64)
+		return messageID
+	case float64:
+		return int64(id)
+	case int64:
+		return id
+	case int32:
+		return int64(id)
+	default:
+		return 0
+	}
+}
+
+// GetRepliedMessage 获取引用回复的原消息
+// 优先从消息历史中查找，找不到时通过 get_msg 获取；消息没有引用时返回 nil
+func (ctx *Context) GetRepliedMessage() (*history.Record, error) {
+	replyID := ctx.GetReplyID()
+	if replyID == 0 {
+		return nil, nil
+	}
+
+	if ctx.Bot.History != nil {
+		key := history.ConversationKey(ctx.GetGroupID(), ctx.GetUserID())
+		if r, ok := ctx.Bot.History.Find(key, replyID); ok {
+			return r, nil
+		}
+	}
+
+	resp, err := ctx.API().GetMsg(replyID)
+	if err != nil {
+		return nil, err
+	}
+	return &history.Record{
+		MessageID: resp.Data.MessageID,
+		GroupID:   ctx.GetGroupID(),
+		UserID:    resp.Data.Sender.UserID,
+		Sender:    resp.Data.Sender,
+		Time:      resp.Data.Time,
+		Message:   message.ParseMessage(resp.Data.Message),
+		FromSelf:  resp.Data.Sender.UserID == ctx.Event.GetSelfID(),
+	}, nil
+}
+
 // Reply 回复消息
 // 返回值：消息ID, 错误
 // 消息ID可用于后续操作（如撤回、设置精华等）
-func (ctx *Context) Reply(msg interface{}) (int64, error) {
-	var messageData interface{}
+//
+// 通知事件（如戳一戳、入群、撤回）发生在群内时回复到该群，否则私聊相关用户；
+// 请求事件和元事件没有回复目标，返回 0, nil；长消息转为合并转发时返回 ErrNoReplyTarget
+//
+// opts 可指定长消息的处理方式（如 SplitLongMessage、ForwardLongMessage），
+// 未指定时使用 Bot 配置中的 LongMessage；长消息被切分时返回第一条消息的 ID
+func (ctx *Context) Reply(msg interface{}, opts ...ReplyOption) (int64, error) {
+	var config LongMessageConfig
+	if ctx.Bot != nil && ctx.Bot.Config != nil {
+		config = ctx.Bot.Config.LongMessage
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
 
-	// 转换消息类型
-	switch m := msg.(type) {
-	case string:
-		messageData = []message.MessageSegment{message.Text(m)}
-	case message.Message:
-		messageData = m
-	case []message.MessageSegment:
-		messageData = m
-	case message.MessageSegment:
-		messageData = []message.MessageSegment{m}
-	default:
-		messageData = msg
+	if messageID, handled, err := ctx.replyLong(msg, config); handled {
+		return messageID, err
 	}
 
+	return ctx.reply(msg)
+}
+
+// replyTarget 获取回复目标
+// 消息事件回复到来源群或私聊；通知事件发生在群内时回复到该群，否则私聊相关用户；
+// 其它事件没有回复目标
+func (ctx *Context) replyTarget() (groupID, userID int64, ok bool) {
 	switch evt := ctx.Event.(type) {
 	case *event.PrivateMessageEvent:
-		resp, err := ctx.Bot.API.SendPrivateMsg(evt.UserID, messageData)
-		if err != nil {
-			return 0, err
-		}
-		return resp.Data.MessageID, nil
+		return 0, evt.UserID, true
 	case *event.GroupMessageEvent:
-		resp, err := ctx.Bot.API.SendGroupMsg(evt.GroupID, messageData)
+		return evt.GroupID, 0, true
+	}
+
+	if ctx.Event.GetPostType() != string(types.PostTypeNotice) {
+		return 0, 0, false
+	}
+	if groupID = ctx.GetGroupID(); groupID != 0 {
+		return groupID, 0, true
+	}
+	if userID = ctx.GetUserID(); userID != 0 {
+		return 0, userID, true
+	}
+	return 0, 0, false
+}
+
+// reply 直接回复消息
+func (ctx *Context) reply(msg interface{}) (int64, error) {
+	groupID, userID, ok := ctx.replyTarget()
+	if !ok {
+		return 0, nil
+	}
+	messageData := toMessageData(msg)
+
+	if groupID != 0 {
+		resp, err := ctx.API().SendGroupMsg(groupID, messageData)
 		if err != nil {
 			return 0, err
 		}
 		return resp.Data.MessageID, nil
+	}
+	resp, err := ctx.API().SendPrivateMsg(userID, messageData)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Data.MessageID, nil
+}
+
+// ReplyAsync 以指定优先级排队回复消息
+// 返回的 SendFuture 在消息实际发送后给出消息 ID；没有回复目标的事件返回 nil
+func (ctx *Context) ReplyAsync(msg interface{}, priority api.Priority) *api.SendFuture {
+	groupID, userID, ok := ctx.replyTarget()
+	if !ok {
+		return nil
+	}
+	messageData := toMessageData(msg)
+
+	if groupID != 0 {
+		return ctx.API().SendGroupMsgAsync(groupID, messageData, priority)
+	}
+	return ctx.API().SendPrivateMsgAsync(userID, messageData, priority)
+}
+
+// toMessageData 将各种消息类型转换为发送 API 接受的消息格式
+func toMessageData(msg interface{}) interface{} {
+	switch m := msg.(type) {
+	case string:
+		return []message.MessageSegment{message.Text(m)}
+	case message.Message:
+		return m
+	case []message.MessageSegment:
+		return m
+	case message.MessageSegment:
+		return []message.MessageSegment{m}
 	default:
-		return 0, nil
+		return msg
 	}
 }
 
@@ -271,13 +488,17 @@ func (ctx *Context) LoadData(key string, value interface{}) error {
 	return json.Unmarshal(data, value)
 }
 
-// Set 设置状态
+// Set 设置状态，可在并发执行的处理函数中调用
 func (ctx *Context) Set(key string, value interface{}) {
+	ctx.shared.mu.Lock()
+	defer ctx.shared.mu.Unlock()
 	ctx.State[key] = value
 }
 
-// Get 获取状态
+// Get 获取状态，可在并发执行的处理函数中调用
 func (ctx *Context) Get(key string) (interface{}, bool) {
+	ctx.shared.mu.RLock()
+	defer ctx.shared.mu.RUnlock()
 	value, ok := ctx.State[key]
 	return value, ok
 }
@@ -323,6 +544,60 @@ func (ctx *Context) FriendRequestEvent() (*event.FriendRequestEvent, bool) {
 	return evt, ok
 }
 
+// GroupCardEvent 获取群名片变更事件
+func (ctx *Context) GroupCardEvent() (*event.GroupCardNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.GroupCardNoticeEvent)
+	return evt, ok
+}
+
+// OfflineFileEvent 获取离线文件事件
+func (ctx *Context) OfflineFileEvent() (*event.OfflineFileNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.OfflineFileNoticeEvent)
+	return evt, ok
+}
+
+// EssenceEvent 获取精华消息变更事件
+func (ctx *Context) EssenceEvent() (*event.EssenceNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.EssenceNoticeEvent)
+	return evt, ok
+}
+
+// EmojiLikeEvent 获取群消息表情回应事件
+func (ctx *Context) EmojiLikeEvent() (*event.GroupMsgEmojiLikeNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.GroupMsgEmojiLikeNoticeEvent)
+	return evt, ok
+}
+
+// InputStatusEvent 获取对方正在输入事件
+func (ctx *Context) InputStatusEvent() (*event.InputStatusNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.InputStatusNoticeEvent)
+	return evt, ok
+}
+
+// ClientStatusEvent 获取其他客户端在线状态变更事件
+func (ctx *Context) ClientStatusEvent() (*event.ClientStatusNoticeEvent, bool) {
+	evt, ok := ctx.Event.(*event.ClientStatusNoticeEvent)
+	return evt, ok
+}
+
+// GetNoticeType 获取通知类型，非通知事件返回空字符串
+func (ctx *Context) GetNoticeType() types.NoticeType {
+	if ctx.Event.GetPostType() != string(types.PostTypeNotice) {
+		return ""
+	}
+	v := reflect.ValueOf(ctx.Event)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("NoticeType"); f.IsValid() && f.Kind() == reflect.String {
+		return types.NoticeType(f.String())
+	}
+	return ""
+}
+
 // IsGroupMessage 判断是否是群消息
 func (ctx *Context) IsGroupMessage() bool {
 	_, ok := ctx.Event.(*event.GroupMessageEvent)
@@ -358,6 +633,10 @@ func (ctx *Context) MustPrivateEvent() *event.PrivateMessageEvent {
 // Next 显式标记继续执行后续匹配器
 // 用于表示当前Handler没有处理该消息，应该继续匹配其他Handler
 //
+// 顺序执行模式（DispatchSequential）下，匹配成功的优先级执行完后，
+// 只有其中的处理函数调用了 Next() 才会继续执行更低优先级的匹配器；
+// 并发执行模式下处理函数不会被等待，Next() 没有效果
+//
 // 使用场景：
 //   - 可选功能（如关键词回复）没有匹配到时
 //   - 条件判断后决定不处理时
@@ -376,13 +655,14 @@ func (ctx *Context) MustPrivateEvent() *event.PrivateMessageEvent {
 //	    // 处理逻辑
 //	})
 func (ctx *Context) Next() {
-	ctx.shouldContinue = true
+	ctx.shared.shouldContinue.Store(true)
 }
 
 // Abort 中止后续匹配器的执行
 // 用于表示当前Handler已经完全处理了该消息，不需要其他Handler再处理
 //
-// 注意：由于使用goroutine并发执行，Abort()只能阻止尚未启动的匹配器，
+// 顺序执行模式（DispatchSequential）下，Abort() 后同一优先级和更低优先级的匹配器都不再执行；
+// 并发执行模式下处理函数在各自的 goroutine 中执行，Abort() 只能阻止尚未启动的匹配器，
 // 已经启动的匹配器仍会执行完成。
 //
 // 使用场景：
@@ -400,13 +680,13 @@ func (ctx *Context) Next() {
 //	    }
 //	})
 func (ctx *Context) Abort() {
-	ctx.aborted = true
-	ctx.shouldContinue = false
+	ctx.shared.aborted.Store(true)
+	ctx.shared.shouldContinue.Store(false)
 }
 
 // IsAborted 检查是否已中止
 func (ctx *Context) IsAborted() bool {
-	return ctx.aborted
+	return ctx.shared.aborted.Load()
 }
 
 // ========== 消息操作方法 ==========
@@ -417,28 +697,14 @@ func (ctx *Context) Delete() error {
 	if messageID == 0 {
 		return fmt.Errorf("无法获取消息ID")
 	}
-	return ctx.Bot.API.DeleteMsg(messageID)
+	return ctx.API().DeleteMsg(messageID)
 }
 
 // SendPrivateMessage 发送私聊消息
 func (ctx *Context) SendPrivateMessage(userID int64, msg interface{}) (int64, error) {
-	var messageData interface{}
+	messageData := toMessageData(msg)
 
-	// 转换消息类型
-	switch m := msg.(type) {
-	case string:
-		messageData = []message.MessageSegment{message.Text(m)}
-	case message.Message:
-		messageData = m
-	case []message.MessageSegment:
-		messageData = m
-	case message.MessageSegment:
-		messageData = []message.MessageSegment{m}
-	default:
-		messageData = msg
-	}
-
-	resp, err := ctx.Bot.API.SendPrivateMsg(userID, messageData)
+	resp, err := ctx.API().SendPrivateMsg(userID, messageData)
 	if err != nil {
 		return 0, err
 	}
@@ -447,55 +713,69 @@ func (ctx *Context) SendPrivateMessage(userID int64, msg interface{}) (int64, er
 
 // SendGroupMessage 发送群消息
 func (ctx *Context) SendGroupMessage(groupID int64, msg interface{}) (int64, error) {
-	var messageData interface{}
+	messageData := toMessageData(msg)
 
-	// 转换消息类型
-	switch m := msg.(type) {
-	case string:
-		messageData = []message.MessageSegment{message.Text(m)}
-	case message.Message:
-		messageData = m
-	case []message.MessageSegment:
-		messageData = m
-	case message.MessageSegment:
-		messageData = []message.MessageSegment{m}
-	default:
-		messageData = msg
-	}
-
-	resp, err := ctx.Bot.API.SendGroupMsg(groupID, messageData)
+	resp, err := ctx.API().SendGroupMsg(groupID, messageData)
 	if err != nil {
 		return 0, err
 	}
 	return resp.Data.MessageID, nil
 }
 
+// ========== 请求操作方法 ==========
+
+// Approve 同意当前的加好友或加群请求
+// 加好友请求时 remark 为好友备注，加群请求时忽略
+func (ctx *Context) Approve(remark string) error {
+	switch evt := ctx.Event.(type) {
+	case *event.FriendRequestEvent:
+		return ctx.API().SetFriendAddRequest(evt.Flag, true, remark)
+	case *event.GroupRequestEvent:
+		return ctx.API().SetGroupAddRequest(evt.Flag, string(evt.SubType), true, "")
+	default:
+		return fmt.Errorf("当前事件不是请求事件")
+	}
+}
+
+// Reject 拒绝当前的加好友或加群请求
+// 加群请求时 reason 为拒绝理由，加好友请求时忽略
+func (ctx *Context) Reject(reason string) error {
+	switch evt := ctx.Event.(type) {
+	case *event.FriendRequestEvent:
+		return ctx.API().SetFriendAddRequest(evt.Flag, false, "")
+	case *event.GroupRequestEvent:
+		return ctx.API().SetGroupAddRequest(evt.Flag, string(evt.SubType), false, reason)
+	default:
+		return fmt.Errorf("当前事件不是请求事件")
+	}
+}
+
 // ========== 群组操作方法 ==========
 
 // SetGroupKick 踢出群成员
 func (ctx *Context) SetGroupKick(groupID, userID int64, rejectAddRequest bool) error {
-	return ctx.Bot.API.SetGroupKick(groupID, userID, rejectAddRequest)
+	return ctx.API().SetGroupKick(groupID, userID, rejectAddRequest)
 }
 
 // SetGroupBan 禁言群成员
 // duration: 禁言时长（秒），0 表示解除禁言
 func (ctx *Context) SetGroupBan(groupID, userID int64, duration int64) error {
-	return ctx.Bot.API.SetGroupBan(groupID, userID, int32(duration))
+	return ctx.API().SetGroupBan(groupID, userID, int32(duration))
 }
 
 // SetGroupWholeBan 全体禁言
 func (ctx *Context) SetGroupWholeBan(groupID int64, enable bool) error {
-	return ctx.Bot.API.SetGroupWholeBan(groupID, enable)
+	return ctx.API().SetGroupWholeBan(groupID, enable)
 }
 
 // SetGroupCard 设置群名片
 func (ctx *Context) SetGroupCard(groupID, userID int64, card string) error {
-	return ctx.Bot.API.SetGroupCard(groupID, userID, card)
+	return ctx.API().SetGroupCard(groupID, userID, card)
 }
 
 // SetGroupAdmin 设置群管理员
 func (ctx *Context) SetGroupAdmin(groupID, userID int64, enable bool) error {
-	return ctx.Bot.API.SetGroupAdmin(groupID, userID, enable)
+	return ctx.API().SetGroupAdmin(groupID, userID, enable)
 }
 
 // ========== 权限判断方法 ==========
@@ -0,0 +1,118 @@
+package xbot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestNoticeReplyTarget 测试通知事件的回复目标：群内通知回复到群，好友通知私聊回复
+func TestNoticeReplyTarget(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{SelfID: 10000, Config: &Config{}, API: api.NewClient(d)}
+	notice := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeNotice}
+
+	poke := NewContext(&event.NotifyNoticeEvent{
+		BaseEvent:  notice,
+		NoticeType: types.NoticeTypeNotify,
+		SubType:    types.NotifySubTypePoke,
+		GroupID:    100,
+		UserID:     1,
+		TargetID:   10000,
+	}, bot)
+	if _, err := poke.Reply("别戳了"); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSendGroupMsg); call == nil || call["group_id"] != int64(100) {
+		t.Errorf("Expected poke to be replied in group, got %v", call)
+	}
+
+	recall := NewContext(&event.FriendRecallNoticeEvent{
+		BaseEvent:  notice,
+		NoticeType: types.NoticeTypeFriendRecall,
+		UserID:     2,
+		MessageID:  5,
+	}, bot)
+	if _, err := recall.Reply("看到了"); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSendPrivateMsg); call == nil || call["user_id"] != int64(2) {
+		t.Errorf("Expected friend recall to be replied privately, got %v", call)
+	}
+}
+
+// TestApproveReject 测试同意和拒绝请求调用的 API 及参数
+func TestApproveReject(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{SelfID: 10000, Config: &Config{}, API: api.NewClient(d)}
+	request := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeRequest}
+
+	friend := NewContext(&event.FriendRequestEvent{BaseEvent: request, UserID: 1, Flag: "f1"}, bot)
+	if err := friend.Approve("老朋友"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSetFriendAddRequest); call == nil || call["flag"] != "f1" || call["approve"] != true || call["remark"] != "老朋友" {
+		t.Errorf("Unexpected friend approve call: %v", call)
+	}
+	if err := friend.Reject("不认识"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSetFriendAddRequest); call["flag"] != "f1" || call["approve"] != false || call["remark"] != "" {
+		t.Errorf("Unexpected friend reject call: %v", call)
+	}
+
+	group := NewContext(&event.GroupRequestEvent{
+		BaseEvent: request,
+		SubType:   types.GroupRequestSubTypeInvite,
+		GroupID:   100,
+		UserID:    1,
+		Flag:      "g1",
+	}, bot)
+	if err := group.Approve("忽略"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call == nil || call["flag"] != "g1" || call["sub_type"] != "invite" || call["approve"] != true || call["reason"] != "" {
+		t.Errorf("Unexpected group approve call: %v", call)
+	}
+	if err := group.Reject("暂不接受邀请"); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call["flag"] != "g1" || call["sub_type"] != "invite" || call["approve"] != false || call["reason"] != "暂不接受邀请" {
+		t.Errorf("Unexpected group reject call: %v", call)
+	}
+
+	notice := NewContext(&event.FriendRecallNoticeEvent{BaseEvent: event.BaseEvent{PostType: types.PostTypeNotice}}, bot)
+	if err := notice.Approve(""); err == nil {
+		t.Error("Expected Approve on non-request event to fail")
+	}
+}
+
+// TestReplyForwardTarget 测试没有配置时的回复，以及没有回复目标时合并转发返回错误
+func TestReplyForwardTarget(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{SelfID: 10000, API: api.NewClient(d)}
+	long := strings.Repeat("很长的消息\n", 10)
+
+	private := NewContext(&event.PrivateMessageEvent{
+		BaseEvent: event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		UserID:    1,
+	}, bot)
+	if _, err := private.Reply("hi"); err != nil {
+		t.Fatalf("Reply without config failed: %v", err)
+	}
+	if _, err := private.Reply(long, ForwardLongMessage(10)); err != nil || d.lastCall(api.ActionSendPrivateForwardMsg) == nil {
+		t.Fatalf("Expected forward message without config, got %v", err)
+	}
+
+	request := NewContext(&event.FriendRequestEvent{
+		BaseEvent: event.BaseEvent{SelfID: 10000, PostType: types.PostTypeRequest},
+		UserID:    1,
+	}, bot)
+	if _, err := request.Reply(long, ForwardLongMessage(10)); !errors.Is(err, ErrNoReplyTarget) {
+		t.Errorf("Expected ErrNoReplyTarget, got %v", err)
+	}
+}
@@ -0,0 +1,542 @@
+package xbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/session"
+	"github.com/xiaoyi510/xbot/types"
+	"github.com/xiaoyi510/xbot/utils"
+)
+
+// 多轮对话
+// 对话由若干状态组成，每个状态发送提示、校验用户输入并转换到下一个状态；
+// 对话进度保存在会话存储中，使用持久化存储时重启后可以继续，提醒和超时也会重新计时；
+// 对话匹配器默认不阻止其他匹配器，其他匹配器通过 NotInDialog 过滤器跳过对话中的用户，或调用 Block 让对话优先处理：
+//
+//	register := engine.NewDialog("register").
+//	    State("name", xbot.DialogState{Prompt: "请输入昵称", Key: "name", Next: "age"}).
+//	    State("age", xbot.DialogState{Prompt: "请输入年龄", Key: "age", Validate: xbot.ValidateInt}).
+//	    OnFinish(func(ctx *xbot.Context, s *xbot.DialogSession) {
+//	        ctx.Reply("注册成功：" + s.Get("name"))
+//	    })
+//
+//	engine.OnCommand("注册").Handle(register.Start)
+//	engine.OnMessage(xbot.NotInDialog()).Handle(chat)
+
+// DialogPriority 调用 Dialog.Block 后对话匹配器的优先级，高于普通匹配器
+const DialogPriority = 1 << 30
+
+// dialogLockShards 对话输入锁的分片数，同一会话的输入和定时器回调依次处理
+const dialogLockShards = 64
+
+// DefaultDialogTimeout 对话每一步的默认超时时间
+const DefaultDialogTimeout = 5 * time.Minute
+
+// dialogSessionKey 对话进度在 session.Session.Data 中的键
+const dialogSessionKey = "dialog"
+
+// dialogSessionGrace 会话比当前状态超时多保留的时间，超时定时器触发时仍能读取对话进度
+const dialogSessionGrace = time.Minute
+
+// DialogState 对话状态
+type DialogState struct {
+	Prompt     string                                                    // 进入该状态时发送的提示，为空时不发送
+	Key        string                                                    // 用户输入保存到对话数据中的键，为空时不保存
+	Validate   func(input string) error                                  // 校验用户输入，返回错误时回复错误信息并停留在当前状态
+	Next       string                                                    // 下一个状态，为空时对话结束
+	Transition func(ctx *Context, s *DialogSession, input string) string // 根据输入决定下一个状态，设置时忽略 Next
+}
+
+// DialogSession 对话进度
+type DialogSession struct {
+	Dialog   string            // 对话名称
+	State    string            // 当前状态
+	History  []string          // 经过的状态，用于返回上一步
+	Data     map[string]string // 收集的数据
+	StepAt   time.Time         // 进入当前状态的时间
+	ExpireAt time.Time         // 当前状态的超时时间
+}
+
+// Get 获取对话数据
+func (s *DialogSession) Get(key string) string {
+	return s.Data[key]
+}
+
+// Set 设置对话数据
+func (s *DialogSession) Set(key, value string) {
+	if s.Data == nil {
+		s.Data = make(map[string]string)
+	}
+	s.Data[key] = value
+}
+
+// Dialog 多轮对话
+type Dialog struct {
+	name        string
+	states      map[string]DialogState
+	start       string
+	timeout     time.Duration
+	remindAfter time.Duration
+	remindText  string
+	timeoutText string
+	cancelWords []string
+	backWords   []string
+	onFinish    func(ctx *Context, s *DialogSession)
+	onCancel    func(ctx *Context, s *DialogSession)
+	onTimeout   func(ctx *Context, s *DialogSession)
+
+	matcher *Matcher                     // 处理对话输入的匹配器
+	timers  sync.Map                     // 会话 key -> *dialogTimers
+	locks   [dialogLockShards]sync.Mutex // 按会话 key 分片的锁
+}
+
+// dialogTimers 当前状态的提醒和超时定时器
+type dialogTimers struct {
+	remind  *time.Timer
+	timeout *time.Timer
+}
+
+// NewDialog 创建多轮对话并在引擎中注册处理对话输入的匹配器
+// 对话名称在所有引擎中应唯一，重启后按名称恢复对话的定时器
+func (e *Engine) NewDialog(name string) *Dialog {
+	d := &Dialog{
+		name:        name,
+		states:      make(map[string]DialogState),
+		timeout:     DefaultDialogTimeout,
+		timeoutText: "对话已超时",
+		cancelWords: []string{"取消", "退出"},
+		backWords:   []string{"上一步", "返回"},
+	}
+
+	d.matcher = e.addEventMatcher("dialog:"+name, d.match, nil).Handle(d.handle)
+
+	e.mu.Lock()
+	e.dialogs = append(e.dialogs, d)
+	e.mu.Unlock()
+	return d
+}
+
+// Block 对话优先处理用户输入：匹配器使用 DialogPriority 优先级并阻止后续匹配，
+// 对话中的消息不再交给其他匹配器，不需要在其他匹配器上使用 NotInDialog 过滤器
+func (d *Dialog) Block() *Dialog {
+	d.matcher.Priority(DialogPriority).SetBlock()
+	return d
+}
+
+// State 添加对话状态，第一个添加的状态为初始状态
+func (d *Dialog) State(name string, state DialogState) *Dialog {
+	if d.start == "" {
+		d.start = name
+	}
+	d.states[name] = state
+	return d
+}
+
+// Timeout 设置每一步的超时时间，超时后结束对话并回复 text（为空时使用默认提示）
+func (d *Dialog) Timeout(timeout time.Duration, text string) *Dialog {
+	d.timeout = timeout
+	if text != "" {
+		d.timeoutText = text
+	}
+	return d
+}
+
+// Remind 设置进入状态 after 后仍未回复时发送提醒
+func (d *Dialog) Remind(after time.Duration, text string) *Dialog {
+	d.remindAfter = after
+	d.remindText = text
+	return d
+}
+
+// CancelWords 设置取消对话的关键词，默认为 "取消"、"退出"
+func (d *Dialog) CancelWords(words ...string) *Dialog {
+	d.cancelWords = words
+	return d
+}
+
+// BackWords 设置返回上一步的关键词，默认为 "上一步"、"返回"
+func (d *Dialog) BackWords(words ...string) *Dialog {
+	d.backWords = words
+	return d
+}
+
+// OnFinish 设置对话完成时的回调
+func (d *Dialog) OnFinish(fn func(ctx *Context, s *DialogSession)) *Dialog {
+	d.onFinish = fn
+	return d
+}
+
+// OnCancel 设置用户取消对话时的回调，未设置时回复 "已取消"
+func (d *Dialog) OnCancel(fn func(ctx *Context, s *DialogSession)) *Dialog {
+	d.onCancel = fn
+	return d
+}
+
+// OnTimeout 设置对话超时时的回调
+func (d *Dialog) OnTimeout(fn func(ctx *Context, s *DialogSession)) *Dialog {
+	d.onTimeout = fn
+	return d
+}
+
+// Start 为当前用户开始对话，已有的对话会被替换
+// 可直接作为处理函数：engine.OnCommand("注册").Handle(dialog.Start)
+func (d *Dialog) Start(ctx *Context) error {
+	if d.start == "" {
+		return fmt.Errorf("对话 %s 没有任何状态", d.name)
+	}
+	return d.enter(ctx, &DialogSession{Dialog: d.name, Data: make(map[string]string)}, d.start)
+}
+
+// match 当前用户是否在本对话中
+func (d *Dialog) match(ctx *Context) bool {
+	s := ctx.CurrentDialog()
+	return s != nil && s.Dialog == d.name
+}
+
+// lock 锁定会话，同一会话的输入和定时器回调依次处理，返回解锁函数
+func (d *Dialog) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &d.locks[h.Sum32()%dialogLockShards]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// handle 处理对话中的用户输入
+// 匹配后重新读取对话进度，同一用户连续发送的消息依次处理，不会基于同一进度重复转换状态
+func (d *Dialog) handle(ctx *Context) error {
+	defer d.lock(dialogKey(ctx))()
+
+	s := loadDialogSession(ctx)
+	if s == nil || s.Dialog != d.name {
+		return nil
+	}
+
+	input := strings.TrimSpace(ctx.GetPlainText())
+	switch {
+	case slices.Contains(d.cancelWords, input):
+		d.finish(ctx)
+		if d.onCancel != nil {
+			d.onCancel(ctx, s)
+		} else {
+			ctx.Reply("已取消")
+		}
+		return nil
+	case slices.Contains(d.backWords, input):
+		if len(s.History) == 0 {
+			return NewUserError("已经是第一步了")
+		}
+		prev := s.History[len(s.History)-1]
+		s.History = s.History[:len(s.History)-1]
+		return d.enter(ctx, s, prev)
+	}
+
+	state, ok := d.states[s.State]
+	if !ok {
+		d.finish(ctx)
+		return fmt.Errorf("对话 %s 的状态 %s 不存在", d.name, s.State)
+	}
+
+	if state.Validate != nil {
+		if err := state.Validate(input); err != nil {
+			return NewUserError(err.Error())
+		}
+	}
+	if state.Key != "" {
+		s.Set(state.Key, input)
+	}
+
+	next := state.Next
+	if state.Transition != nil {
+		next = state.Transition(ctx, s, input)
+	}
+	if next == "" {
+		d.finish(ctx)
+		if d.onFinish != nil {
+			d.onFinish(ctx, s)
+		}
+		return nil
+	}
+	if _, ok := d.states[next]; !ok {
+		d.finish(ctx)
+		return fmt.Errorf("对话 %s 的状态 %s 不存在", d.name, next)
+	}
+
+	s.History = append(s.History, s.State)
+	return d.enter(ctx, s, next)
+}
+
+// enter 进入状态：保存进度、发送提示并设置提醒和超时
+func (d *Dialog) enter(ctx *Context, s *DialogSession, state string) error {
+	now := time.Now()
+	s.State = state
+	s.StepAt = now
+	s.ExpireAt = now.Add(d.timeout)
+
+	if err := saveDialogSession(ctx, s, d.timeout+dialogSessionGrace); err != nil {
+		return err
+	}
+	if prompt := d.states[state].Prompt; prompt != "" {
+		ctx.Reply(prompt)
+	}
+
+	d.arm(ctx, s)
+	return nil
+}
+
+// arm 按对话进度设置当前状态的提醒和超时定时器，重启后恢复时只等待剩余的时间；
+// 定时器触发时状态已变化则不处理
+func (d *Dialog) arm(ctx *Context, s *DialogSession) {
+	key := dialogKey(ctx)
+	d.stopTimers(key)
+
+	stepAt := s.StepAt
+	current := func() *DialogSession {
+		s := readDialogSession(ctx)
+		if s == nil || s.Dialog != d.name || !s.StepAt.Equal(stepAt) {
+			return nil
+		}
+		return s
+	}
+
+	t := &dialogTimers{}
+	if d.remindAfter > 0 && d.remindAfter < d.timeout && d.remindText != "" {
+		// 重启时已过提醒时间则不再提醒
+		if wait := time.Until(stepAt.Add(d.remindAfter)); wait >= 0 {
+			t.remind = time.AfterFunc(wait, func() {
+				defer d.lock(key)()
+				if current() != nil {
+					ctx.Reply(d.remindText)
+				}
+			})
+		}
+	}
+	t.timeout = time.AfterFunc(max(time.Until(s.ExpireAt), 0), func() {
+		defer d.lock(key)()
+		s := current()
+		if s == nil {
+			return
+		}
+		d.finish(ctx)
+		ctx.Reply(d.timeoutText)
+		if d.onTimeout != nil {
+			d.onTimeout(ctx, s)
+		}
+	})
+	d.timers.Store(key, t)
+}
+
+// stopTimers 停止定时器
+func (d *Dialog) stopTimers(key string) {
+	value, ok := d.timers.LoadAndDelete(key)
+	if !ok {
+		return
+	}
+	t := value.(*dialogTimers)
+	if t.remind != nil {
+		t.remind.Stop()
+	}
+	t.timeout.Stop()
+}
+
+// finish 结束对话并清除进度
+func (d *Dialog) finish(ctx *Context) {
+	d.stopTimers(dialogKey(ctx))
+	clearDialogSession(ctx)
+}
+
+// restoreDialogs 恢复会话存储中未结束的对话的提醒和超时定时器，用于重启后继续计时；
+// 重启期间已超时的对话立即按超时处理
+func restoreDialogs(bot *Bot) {
+	if bot.SessionManager == nil {
+		return
+	}
+	dialogs := make(map[string]*Dialog)
+	for _, e := range bot.engines {
+		e.mu.RLock()
+		for _, d := range e.dialogs {
+			dialogs[d.name] = d
+		}
+		e.mu.RUnlock()
+	}
+	if len(dialogs) == 0 {
+		return
+	}
+
+	bot.SessionManager.Range(func(sess *session.Session) bool {
+		s := decodeDialogSession(sess)
+		if s == nil || dialogs[s.Dialog] == nil {
+			return true
+		}
+		// 定时器使用以该用户身份构造的消息事件回复，回复到原来的群或私聊
+		ctx := NewContext(dialogEvent(bot.SelfID, sess.UserID, sess.GroupID), bot)
+		dialogs[s.Dialog].arm(ctx, s)
+		return true
+	})
+}
+
+// dialogEvent 构造对话所在会话的消息事件，用于恢复的定时器回复用户
+func dialogEvent(selfID, userID, groupID int64) event.Event {
+	base := event.BaseEvent{SelfID: selfID, PostType: types.PostTypeMessage}
+	if groupID != 0 {
+		return &event.GroupMessageEvent{BaseEvent: base, GroupID: groupID, UserID: userID}
+	}
+	return &event.PrivateMessageEvent{BaseEvent: base, UserID: userID}
+}
+
+// ========== 对话进度存储 ==========
+
+// dialogKey 对话定时器和锁的 key，由机器人账号和会话 key 组成
+func dialogKey(ctx *Context) string {
+	var selfID int64
+	if ctx.Bot != nil {
+		selfID = ctx.Bot.SelfID
+	}
+	return fmt.Sprintf("%d:%s", selfID, utils.GenerateSessionKey(ctx.GetUserID(), ctx.GetGroupID()))
+}
+
+// isDialogInput 是否为可以作为对话输入的消息事件
+func isDialogInput(ctx *Context) bool {
+	switch ctx.Event.(type) {
+	case *event.PrivateMessageEvent, *event.GroupMessageEvent:
+		return ctx.Session != nil
+	default:
+		return false
+	}
+}
+
+// loadDialogSession 从会话中读取对话进度，不存在或已超时时返回 nil
+func loadDialogSession(ctx *Context) *DialogSession {
+	s := readDialogSession(ctx)
+	if s == nil || time.Now().After(s.ExpireAt) {
+		return nil
+	}
+	return s
+}
+
+// readDialogSession 从会话中读取对话进度，包括已超时但尚未清除的进度
+func readDialogSession(ctx *Context) *DialogSession {
+	if ctx.Session == nil {
+		return nil
+	}
+	sess, ok := ctx.Session.Get(ctx.GetUserID(), ctx.GetGroupID())
+	if !ok {
+		return nil
+	}
+	return decodeDialogSession(sess)
+}
+
+// decodeDialogSession 解析会话中的对话进度，不存在或无法解析时返回 nil
+func decodeDialogSession(sess *session.Session) *DialogSession {
+	data, ok := sess.Data[dialogSessionKey].(string)
+	if !ok {
+		return nil
+	}
+
+	var s DialogSession
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		logger.Warn("解析对话进度失败", "session", sess.ID, "error", err)
+		return nil
+	}
+	return &s
+}
+
+// saveDialogSession 将对话进度保存到会话中
+func saveDialogSession(ctx *Context, s *DialogSession, ttl time.Duration) error {
+	if ctx.Session == nil {
+		return fmt.Errorf("会话管理器未初始化")
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	userID, groupID := ctx.GetUserID(), ctx.GetGroupID()
+	sess, ok := ctx.Session.Get(userID, groupID)
+	if !ok {
+		sess = &session.Session{
+			ID:        utils.GenerateSessionKey(userID, groupID),
+			UserID:    userID,
+			GroupID:   groupID,
+			CreatedAt: time.Now(),
+		}
+	}
+	if sess.Data == nil {
+		sess.Data = make(map[string]interface{})
+	}
+	sess.Data[dialogSessionKey] = string(data)
+	return ctx.Session.SetWithTTL(sess, ttl)
+}
+
+// clearDialogSession 清除会话中的对话进度
+func clearDialogSession(ctx *Context) {
+	if ctx.Session == nil {
+		return
+	}
+	userID, groupID := ctx.GetUserID(), ctx.GetGroupID()
+	sess, ok := ctx.Session.Get(userID, groupID)
+	if !ok {
+		return
+	}
+	delete(sess.Data, dialogSessionKey)
+	if len(sess.Data) == 0 {
+		ctx.Session.Delete(userID, groupID)
+		return
+	}
+	ctx.Session.Set(sess)
+}
+
+// CurrentDialog 获取事件到达时当前用户正在进行的对话，不在对话中时返回 nil
+// 同一事件只读取一次对话进度，所有匹配器和过滤器看到的结果一致：
+// 结束对话的那条消息不会因为对话已结束而被 NotInDialog 的匹配器处理
+func (ctx *Context) CurrentDialog() *DialogSession {
+	if !isDialogInput(ctx) {
+		return nil
+	}
+	ctx.shared.dialogOnce.Do(func() {
+		ctx.shared.dialog = loadDialogSession(ctx)
+	})
+	return ctx.shared.dialog
+}
+
+// ========== 对话过滤器与校验 ==========
+
+// InDialog 只处理正在进行对话的用户的消息，names 为空时匹配任意对话
+func InDialog(names ...string) Filter {
+	return func(ctx *Context) bool {
+		s := ctx.CurrentDialog()
+		return s != nil && (len(names) == 0 || slices.Contains(names, s.Dialog))
+	}
+}
+
+// NotInDialog 跳过正在进行对话的用户的消息
+func NotInDialog() Filter {
+	return func(ctx *Context) bool {
+		return ctx.CurrentDialog() == nil
+	}
+}
+
+// ValidateInt 校验输入为整数
+func ValidateInt(input string) error {
+	if _, err := strconv.ParseInt(input, 10, 64); err != nil {
+		return fmt.Errorf("请输入整数")
+	}
+	return nil
+}
+
+// ValidateNotEmpty 校验输入不为空
+func ValidateNotEmpty(input string) error {
+	if input == "" {
+		return fmt.Errorf("输入不能为空")
+	}
+	return nil
+}
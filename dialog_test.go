@@ -0,0 +1,191 @@
+package xbot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/session"
+	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestDialog 测试对话的状态转换、校验、返回上一步、持久化和过滤器
+// 对话默认不阻止其他匹配器，对话中的消息只由 NotInDialog 过滤器挡住
+func TestDialog(t *testing.T) {
+	d := &recordDriver{}
+	store := storage.NewMemoryStorage()
+	bot := &Bot{
+		SelfID:         10000,
+		Config:         &Config{CommandPrefix: "/", DispatchMode: DispatchSequential},
+		API:            api.NewClient(d),
+		SessionManager: session.NewManager(session.NewStorageStore(store, "session:"), time.Hour),
+	}
+	e := &Engine{name: "test"}
+	e.bot = bot
+
+	var result map[string]string
+	register := e.NewDialog("register").
+		State("name", DialogState{Prompt: "请输入昵称", Key: "name", Next: "age"}).
+		State("age", DialogState{Prompt: "请输入年龄", Key: "age", Validate: ValidateInt}).
+		OnFinish(func(ctx *Context, s *DialogSession) { result = s.Data })
+	e.OnCommand("注册").SetBlock().Handle(register.Start)
+
+	chatted, observed := 0, 0
+	e.OnMessage(NotInDialog()).Handle(func(ctx *Context) { chatted++ })
+	e.OnMessage().Handle(func(ctx *Context) { observed++ })
+
+	send := func(text string) {
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			UserID:        1,
+			ParsedMessage: message.Message{message.Text(text)},
+		})
+	}
+	lastReply := func() string {
+		return fmt.Sprint(d.lastCall(api.ActionSendPrivateMsg)["message"])
+	}
+
+	send("/注册")
+	if !strings.Contains(lastReply(), "请输入昵称") {
+		t.Fatalf("Expected name prompt, got %s", lastReply())
+	}
+
+	send("小明")
+	if !strings.Contains(lastReply(), "请输入年龄") {
+		t.Fatalf("Expected age prompt, got %s", lastReply())
+	}
+
+	send("上一步")
+	if !strings.Contains(lastReply(), "请输入昵称") {
+		t.Fatalf("Expected back to name prompt, got %s", lastReply())
+	}
+	send("小红")
+
+	// 重新创建会话管理器，模拟重启后继续对话
+	bot.SessionManager = session.NewManager(session.NewStorageStore(store, "session:"), time.Hour)
+
+	send("十八")
+	if !strings.Contains(lastReply(), "请输入整数") {
+		t.Fatalf("Expected validation error, got %s", lastReply())
+	}
+	send("18")
+
+	if result["name"] != "小红" || result["age"] != "18" {
+		t.Errorf("Expected dialog data, got %v", result)
+	}
+	if chatted != 0 {
+		t.Errorf("Expected messages in dialog to skip NotInDialog matchers, got %d", chatted)
+	}
+	if observed != 5 {
+		t.Errorf("Expected dialog not to block unfiltered matchers, got %d", observed)
+	}
+
+	send("你好")
+	if chatted != 1 {
+		t.Errorf("Expected message after dialog to reach other matchers, got %d", chatted)
+	}
+}
+
+// newDialogTestBot 创建对话测试使用的机器人
+func newDialogTestBot(d *recordDriver, store storage.Storage) *Bot {
+	return &Bot{
+		SelfID:         10000,
+		Config:         &Config{CommandPrefix: "/", DispatchMode: DispatchSequential},
+		API:            api.NewClient(d),
+		SessionManager: session.NewManager(session.NewStorageStore(store, "session:"), time.Hour),
+	}
+}
+
+// waitReply 等待机器人发送包含 text 的私聊消息
+func waitReply(t *testing.T, d *recordDriver, text string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if call := d.lastCall(api.ActionSendPrivateMsg); call != nil && strings.Contains(fmt.Sprint(call["message"]), text) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected reply %q, last %v", text, d.lastCall(api.ActionSendPrivateMsg))
+}
+
+// TestDialogTimeout 测试提醒、超时以及重启后恢复定时器
+func TestDialogTimeout(t *testing.T) {
+	d := &recordDriver{}
+	store := storage.NewMemoryStorage()
+	bot := newDialogTestBot(d, store)
+	e := &Engine{name: "test", bot: bot}
+	bot.engines = []*Engine{e}
+
+	timedOut := make(chan string, 1)
+	dialog := e.NewDialog("quiz").
+		State("q", DialogState{Prompt: "请回答"}).
+		Timeout(300*time.Millisecond, "回答超时").
+		Remind(100*time.Millisecond, "还在吗").
+		OnTimeout(func(ctx *Context, s *DialogSession) { timedOut <- s.State })
+	e.OnCommand("quiz").Handle(dialog.Start)
+
+	send := func() {
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			UserID:        1,
+			ParsedMessage: message.Message{message.Text("/quiz")},
+		})
+	}
+
+	send()
+	waitReply(t, d, "还在吗")
+	waitReply(t, d, "回答超时")
+	if state := <-timedOut; state != "q" {
+		t.Errorf("Expected timeout in state q, got %s", state)
+	}
+	if s := loadDialogSession(NewContext(dialogEvent(10000, 1, 0), bot)); s != nil {
+		t.Errorf("Expected dialog to be cleared after timeout, got %v", s)
+	}
+
+	// 停止当前定时器模拟重启，恢复后按剩余时间超时
+	send()
+	dialog.stopTimers(dialogKey(NewContext(dialogEvent(10000, 1, 0), bot)))
+	restored := newDialogTestBot(d, store)
+	restored.engines = []*Engine{e}
+	restoreDialogs(restored)
+	waitReply(t, d, "回答超时")
+	select {
+	case <-timedOut:
+	case <-time.After(time.Second):
+		t.Fatal("Expected restored dialog to time out")
+	}
+}
+
+// TestDialogBlock 测试 Block 后对话输入不再交给其他匹配器
+func TestDialogBlock(t *testing.T) {
+	d := &recordDriver{}
+	bot := newDialogTestBot(d, storage.NewMemoryStorage())
+	e := &Engine{name: "test", bot: bot}
+
+	var answers []string
+	echo := e.NewDialog("echo").
+		State("say", DialogState{Prompt: "说点什么", Key: "text"}).
+		OnFinish(func(ctx *Context, s *DialogSession) { answers = append(answers, s.Get("text")) }).
+		Block()
+	e.OnCommand("echo").SetBlock().Handle(echo.Start)
+
+	observed := 0
+	e.OnMessage().Handle(func(ctx *Context) { observed++ })
+
+	for _, text := range []string{"/echo", "你好"} {
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			UserID:        1,
+			ParsedMessage: message.Message{message.Text(text)},
+		})
+	}
+	if len(answers) != 1 || answers[0] != "你好" || observed != 0 {
+		t.Errorf("Expected blocking dialog to consume input, got %v and %d", answers, observed)
+	}
+}
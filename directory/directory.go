@@ -0,0 +1,369 @@
+package directory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// DefaultTTL 默认缓存有效期
+const DefaultTTL = 10 * time.Minute
+
+// memberRecord 群成员缓存记录
+type memberRecord struct {
+	info      types.GroupMemberInfo
+	fetchedAt time.Time
+	partial   bool // 由通知事件生成，缺少昵称、名片等详细信息
+}
+
+// groupMembers 单个群的成员缓存
+type groupMembers struct {
+	members  map[int64]*memberRecord
+	complete bool      // 是否已加载完整成员列表
+	loadedAt time.Time // 完整成员列表的加载时间
+}
+
+// Directory 群、群成员和好友信息缓存
+// 数据在首次访问时通过 API 懒加载，过期后重新加载；
+// 同时根据群成员增减、管理员变动、名片变更等通知事件增量更新，减少 API 调用。
+type Directory struct {
+	client *api.Client
+	ttl    time.Duration
+
+	mu            sync.RWMutex
+	groups        map[int64]types.GroupInfo
+	groupsLoaded  time.Time
+	members       map[int64]*groupMembers
+	friends       map[int64]types.FriendInfo
+	friendsLoaded time.Time
+}
+
+// New 创建缓存，ttl 为 0 时使用 DefaultTTL
+func New(client *api.Client, ttl time.Duration) *Directory {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Directory{
+		client:  client,
+		ttl:     ttl,
+		members: make(map[int64]*groupMembers),
+	}
+}
+
+// fresh 判断加载时间是否仍在有效期内
+func (d *Directory) fresh(loadedAt time.Time) bool {
+	return !loadedAt.IsZero() && time.Since(loadedAt) < d.ttl
+}
+
+// ========== 群 ==========
+
+// Groups 获取群列表
+func (d *Directory) Groups() ([]types.GroupInfo, error) {
+	if err := d.loadGroups(false); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	groups := make([]types.GroupInfo, 0, len(d.groups))
+	for _, g := range d.groups {
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// Group 获取群信息，机器人不在该群时返回 false
+func (d *Directory) Group(groupID int64) (types.GroupInfo, bool, error) {
+	if err := d.loadGroups(false); err != nil {
+		return types.GroupInfo{}, false, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	g, ok := d.groups[groupID]
+	return g, ok, nil
+}
+
+// loadGroups 加载群列表，force 为 true 时忽略缓存
+func (d *Directory) loadGroups(force bool) error {
+	d.mu.RLock()
+	loaded := d.fresh(d.groupsLoaded)
+	d.mu.RUnlock()
+	if loaded && !force {
+		return nil
+	}
+
+	resp, err := d.client.GetGroupList()
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[int64]types.GroupInfo, len(resp.Data))
+	for _, g := range resp.Data {
+		groups[g.GroupID] = g
+	}
+
+	d.mu.Lock()
+	d.groups = groups
+	d.groupsLoaded = time.Now()
+	d.mu.Unlock()
+	return nil
+}
+
+// ========== 群成员 ==========
+
+// Members 获取群成员列表
+func (d *Directory) Members(groupID int64) ([]types.GroupMemberInfo, error) {
+	gm, err := d.loadMembers(groupID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	members := make([]types.GroupMemberInfo, 0, len(gm.members))
+	for _, rec := range gm.members {
+		members = append(members, rec.info)
+	}
+	return members, nil
+}
+
+// IsMember 判断用户是否在群内
+func (d *Directory) IsMember(groupID, userID int64) (bool, error) {
+	gm, err := d.loadMembers(groupID, false)
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := gm.members[userID]
+	return ok, nil
+}
+
+// Member 获取群成员信息
+// 优先使用缓存，缓存中没有或已过期时通过 get_group_member_info 单独获取
+func (d *Directory) Member(groupID, userID int64) (types.GroupMemberInfo, error) {
+	d.mu.RLock()
+	if gm, ok := d.members[groupID]; ok {
+		if rec, ok := gm.members[userID]; ok && !rec.partial && d.fresh(rec.fetchedAt) {
+			d.mu.RUnlock()
+			return rec.info, nil
+		}
+	}
+	d.mu.RUnlock()
+
+	resp, err := d.client.GetGroupMemberInfo(groupID, userID, false)
+	if err != nil {
+		return types.GroupMemberInfo{}, err
+	}
+
+	d.mu.Lock()
+	d.groupMembers(groupID).members[userID] = &memberRecord{info: resp.Data, fetchedAt: time.Now()}
+	d.mu.Unlock()
+
+	return resp.Data, nil
+}
+
+// loadMembers 加载完整的群成员列表，force 为 true 时忽略缓存
+// 返回本次使用的群成员缓存，调用方读取成员时需持有读锁；
+// 返回后缓存可能被 Invalidate 或退群事件从 d.members 中移除，因此不能再按 groupID 重新查找
+func (d *Directory) loadMembers(groupID int64, force bool) (*groupMembers, error) {
+	d.mu.RLock()
+	gm, ok := d.members[groupID]
+	loaded := ok && gm.complete && d.fresh(gm.loadedAt)
+	d.mu.RUnlock()
+	if loaded && !force {
+		return gm, nil
+	}
+
+	resp, err := d.client.GetGroupMemberList(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	members := make(map[int64]*memberRecord, len(resp.Data))
+	for _, m := range resp.Data {
+		members[m.UserID] = &memberRecord{info: m, fetchedAt: now}
+	}
+
+	gm = &groupMembers{members: members, complete: true, loadedAt: now}
+	d.mu.Lock()
+	d.members[groupID] = gm
+	d.mu.Unlock()
+	return gm, nil
+}
+
+// groupMembers 获取或创建群成员缓存，调用方需持有写锁
+func (d *Directory) groupMembers(groupID int64) *groupMembers {
+	gm, ok := d.members[groupID]
+	if !ok {
+		gm = &groupMembers{members: make(map[int64]*memberRecord)}
+		d.members[groupID] = gm
+	}
+	return gm
+}
+
+// ========== 好友 ==========
+
+// Friends 获取好友列表
+func (d *Directory) Friends() ([]types.FriendInfo, error) {
+	if err := d.loadFriends(false); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	friends := make([]types.FriendInfo, 0, len(d.friends))
+	for _, f := range d.friends {
+		friends = append(friends, f)
+	}
+	return friends, nil
+}
+
+// IsFriend 判断用户是否是好友
+func (d *Directory) IsFriend(userID int64) (bool, error) {
+	if err := d.loadFriends(false); err != nil {
+		return false, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.friends[userID]
+	return ok, nil
+}
+
+// loadFriends 加载好友列表，force 为 true 时忽略缓存
+func (d *Directory) loadFriends(force bool) error {
+	d.mu.RLock()
+	loaded := d.fresh(d.friendsLoaded)
+	d.mu.RUnlock()
+	if loaded && !force {
+		return nil
+	}
+
+	resp, err := d.client.GetFriendList()
+	if err != nil {
+		return err
+	}
+
+	friends := make(map[int64]types.FriendInfo, len(resp.Data))
+	for _, f := range resp.Data {
+		friends[f.UserID] = f
+	}
+
+	d.mu.Lock()
+	d.friends = friends
+	d.friendsLoaded = time.Now()
+	d.mu.Unlock()
+	return nil
+}
+
+// ========== 失效与增量更新 ==========
+
+// Invalidate 使指定群的成员缓存失效
+func (d *Directory) Invalidate(groupID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.members, groupID)
+}
+
+// InvalidateAll 清空所有缓存
+func (d *Directory) InvalidateAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.groups = nil
+	d.groupsLoaded = time.Time{}
+	d.members = make(map[int64]*groupMembers)
+	d.friends = nil
+	d.friendsLoaded = time.Time{}
+}
+
+// HandleEvent 根据通知事件增量更新缓存
+func (d *Directory) HandleEvent(evt event.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch e := evt.(type) {
+	case *event.GroupIncreaseNoticeEvent:
+		if e.UserID == e.SelfID {
+			// 机器人加入新群，群列表需要重新加载
+			d.groupsLoaded = time.Time{}
+			return
+		}
+		// 先记录成员关系，详细信息在访问时再获取
+		if gm, ok := d.members[e.GroupID]; ok {
+			gm.members[e.UserID] = &memberRecord{
+				info: types.GroupMemberInfo{
+					GroupID:  e.GroupID,
+					UserID:   e.UserID,
+					JoinTime: e.Time,
+					Role:     types.RoleMember,
+				},
+				partial: true,
+			}
+		}
+		d.updateMemberCount(e.GroupID, 1)
+
+	case *event.GroupDecreaseNoticeEvent:
+		if e.UserID == e.SelfID || e.SubType == "kick_me" {
+			// 机器人离开群
+			delete(d.groups, e.GroupID)
+			delete(d.members, e.GroupID)
+			return
+		}
+		if gm, ok := d.members[e.GroupID]; ok {
+			delete(gm.members, e.UserID)
+		}
+		d.updateMemberCount(e.GroupID, -1)
+
+	case *event.GroupAdminNoticeEvent:
+		if rec := d.memberRecord(e.GroupID, e.UserID); rec != nil {
+			if e.IsSet() {
+				rec.info.Role = types.RoleAdmin
+			} else {
+				rec.info.Role = types.RoleMember
+			}
+		}
+
+	case *event.GroupCardNoticeEvent:
+		if rec := d.memberRecord(e.GroupID, e.UserID); rec != nil {
+			rec.info.Card = e.CardNew
+		}
+
+	case *event.FriendAddNoticeEvent:
+		// 好友信息（昵称、备注）需要重新获取
+		d.friendsLoaded = time.Time{}
+
+	case *event.GroupMessageEvent:
+		// 群消息中带有发送者的最新名片和角色
+		if rec := d.memberRecord(e.GroupID, e.UserID); rec != nil && !rec.partial {
+			if e.Sender.Card != "" {
+				rec.info.Card = e.Sender.Card
+			}
+			if e.Sender.Role != "" {
+				rec.info.Role = e.Sender.Role
+			}
+		}
+	}
+}
+
+// memberRecord 获取成员缓存记录，调用方需持有锁
+func (d *Directory) memberRecord(groupID, userID int64) *memberRecord {
+	if gm, ok := d.members[groupID]; ok {
+		return gm.members[userID]
+	}
+	return nil
+}
+
+// updateMemberCount 更新群成员数，调用方需持有写锁
+func (d *Directory) updateMemberCount(groupID int64, delta int32) {
+	if g, ok := d.groups[groupID]; ok {
+		g.MemberCount += delta
+		d.groups[groupID] = g
+	}
+}
@@ -0,0 +1,130 @@
+package directory
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/driver"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// fakeDriver 按 API 名称返回预设响应的驱动器
+type fakeDriver struct {
+	mu    sync.Mutex
+	resps map[string]string
+	calls map[string]int
+}
+
+func newFakeDriver(resps map[string]string) *fakeDriver {
+	return &fakeDriver{resps: resps, calls: make(map[string]int)}
+}
+
+func (d *fakeDriver) Connect() error                              { return nil }
+func (d *fakeDriver) SetEventHandler(handler driver.EventHandler) {}
+func (d *fakeDriver) Close() error                                { return nil }
+func (d *fakeDriver) IsConnected() bool                           { return true }
+
+func (d *fakeDriver) CallAPI(action string, params map[string]interface{}) (*types.APIResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls[action]++
+	var resp types.APIResponse
+	if err := json.Unmarshal([]byte(d.resps[action]), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TestMembersCachedAndUpdated 测试成员列表缓存及通知事件增量更新
+func TestMembersCachedAndUpdated(t *testing.T) {
+	d := newFakeDriver(map[string]string{
+		api.ActionGetGroupMemberList: `{"status":"ok","retcode":0,"data":[{"group_id":1,"user_id":10,"role":"member"},{"group_id":1,"user_id":11,"role":"member"}]}`,
+	})
+	dir := New(api.NewClient(d), 0)
+
+	if ok, err := dir.IsMember(1, 10); err != nil || !ok {
+		t.Fatalf("Expected user 10 to be member, got %v, %v", ok, err)
+	}
+
+	base := event.BaseEvent{SelfID: 99}
+	dir.HandleEvent(&event.GroupDecreaseNoticeEvent{BaseEvent: base, SubType: "leave", GroupID: 1, UserID: 10})
+	dir.HandleEvent(&event.GroupIncreaseNoticeEvent{BaseEvent: base, GroupID: 1, UserID: 12})
+	dir.HandleEvent(&event.GroupAdminNoticeEvent{BaseEvent: base, SubType: "set", GroupID: 1, UserID: 11})
+	dir.HandleEvent(&event.GroupCardNoticeEvent{BaseEvent: base, GroupID: 1, UserID: 11, CardNew: "新名片"})
+
+	if ok, _ := dir.IsMember(1, 10); ok {
+		t.Error("Expected user 10 to be removed")
+	}
+	if ok, _ := dir.IsMember(1, 12); !ok {
+		t.Error("Expected user 12 to be added")
+	}
+
+	member, err := dir.Member(1, 11)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if member.Role != types.RoleAdmin || member.Card != "新名片" {
+		t.Errorf("Unexpected member: %+v", member)
+	}
+
+	if n := d.calls[api.ActionGetGroupMemberList]; n != 1 {
+		t.Errorf("Expected 1 member list call, got %d", n)
+	}
+	if n := d.calls[api.ActionGetGroupMemberInfo]; n != 0 {
+		t.Errorf("Expected no member info call, got %d", n)
+	}
+}
+
+// TestInvalidate 测试缓存失效后重新加载
+func TestInvalidate(t *testing.T) {
+	d := newFakeDriver(map[string]string{
+		api.ActionGetGroupMemberList: `{"status":"ok","retcode":0,"data":[{"group_id":1,"user_id":10}]}`,
+	})
+	dir := New(api.NewClient(d), 0)
+
+	dir.Members(1)
+	dir.Members(1)
+	dir.Invalidate(1)
+	dir.Members(1)
+
+	if n := d.calls[api.ActionGetGroupMemberList]; n != 2 {
+		t.Errorf("Expected 2 member list calls, got %d", n)
+	}
+}
+
+// TestMembersConcurrentInvalidate 测试加载成员列表时缓存被并发清除不会导致 panic
+func TestMembersConcurrentInvalidate(t *testing.T) {
+	d := newFakeDriver(map[string]string{
+		api.ActionGetGroupMemberList: `{"status":"ok","retcode":0,"data":[{"group_id":1,"user_id":10,"role":"member"}]}`,
+	})
+	dir := New(api.NewClient(d), 0)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				dir.InvalidateAll()
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		if members, err := dir.Members(1); err != nil || len(members) != 1 {
+			t.Fatalf("Expected 1 member, got %v, %v", members, err)
+		}
+		if ok, err := dir.IsMember(1, 10); err != nil || !ok {
+			t.Fatalf("Expected user 10 to be member, got %v, %v", ok, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
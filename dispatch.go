@@ -0,0 +1,285 @@
+package xbot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xiaoyi510/xbot/metrics"
+	"github.com/xiaoyi510/xbot/trace"
+)
+
+// DispatchMode 匹配器执行方式
+type DispatchMode string
+
+const (
+	// DispatchConcurrent 匹配成功的处理函数在各自的 goroutine 中执行，不等待完成（默认）
+	DispatchConcurrent DispatchMode = "concurrent"
+	// DispatchSequential 同一优先级内依次执行处理函数并等待完成，
+	// 一个优先级中有匹配器匹配成功后，只有处理函数调用了 ctx.Next() 才继续执行更低优先级的匹配器；
+	// 设置 Config.ConcurrentLevels 后各优先级并发执行，优先级内仍依次执行
+	DispatchSequential DispatchMode = "sequential"
+)
+
+// dispatch 将事件分发给所有启用引擎的匹配器
+// 所有引擎共享同一个 Context，匹配器按优先级从高到低全局排序，
+// 因此高优先级匹配器的 SetBlock 和 ctx.Abort() 对所有引擎生效
+func dispatch(ctx *Context, engines []*Engine, cache *matcherCache) {
+	withEngineMiddlewares(ctx, engines, func(ctx *Context, admitted map[*Engine]bool) {
+		idx := cache.get(engines)
+		if ctx.Bot != nil && ctx.Bot.Config != nil && ctx.Bot.Config.DispatchMode == DispatchSequential {
+			dispatchSequential(ctx, idx.levels, admitted, ctx.Bot.Config.ConcurrentLevels)
+			return
+		}
+
+		for _, matcher := range idx.matchers {
+			// 检查是否已中止
+			if ctx.IsAborted() {
+				break
+			}
+			if !admitted[matcher.engine] {
+				continue
+			}
+
+			handlerCtx, ok := matchOne(ctx, matcher)
+			if !ok {
+				continue
+			}
+
+			// 使用 goroutine 处理事件，避免阻塞
+			go runMatcher(handlerCtx, matcher)
+
+			// 检查是否应该阻止继续匹配
+			if matcher.block {
+				break
+			}
+		}
+	})
+}
+
+// withEngineMiddlewares 依次进入各引擎的事件中间件（Engine.UseEvent），最内层执行 run
+// 每个引擎的中间件包裹整个匹配与分发过程，每个事件执行一次，与匹配结果无关；
+// 中间件调用 next 时该引擎参与分发，不调用 next 时该引擎的匹配器不参与匹配，也就不会阻止其他引擎
+func withEngineMiddlewares(ctx *Context, engines []*Engine, run func(ctx *Context, admitted map[*Engine]bool)) {
+	admitted := make(map[*Engine]bool, len(engines))
+
+	var step func(ctx *Context, i int)
+	step = func(ctx *Context, i int) {
+		for ; i < len(engines); i++ {
+			e := engines[i]
+			if !e.IsEnabled() {
+				continue
+			}
+
+			e.mu.RLock()
+			mws := e.eventMiddlewares
+			e.mu.RUnlock()
+			if len(mws) == 0 {
+				admitted[e] = true
+				continue
+			}
+
+			rest := i + 1
+			proceeded := false
+			handler := func(ctx *Context) {
+				if proceeded {
+					return
+				}
+				proceeded = true
+				admitted[e] = true
+				step(ctx, rest)
+			}
+			for j := len(mws) - 1; j >= 0; j-- {
+				handler = mws[j](handler)
+			}
+			handler(ctx)
+
+			// 中间件没有调用 next：跳过该引擎，其余引擎照常分发
+			if !proceeded {
+				proceeded = true
+				step(ctx, rest)
+			}
+			return
+		}
+		run(ctx, admitted)
+	}
+	step(ctx, 0)
+}
+
+// dispatchSequential 按优先级分组执行匹配器，同一优先级内依次匹配并等待处理函数返回
+// 一个优先级中有匹配器匹配成功后，只有处理函数调用了 ctx.Next() 才继续执行更低优先级；
+// concurrentLevels 为 true 时各优先级同时开始执行，优先级之间不再等待，也就不再由 Next() 决定是否继续
+func dispatchSequential(ctx *Context, levels [][]*Matcher, admitted map[*Engine]bool, concurrentLevels bool) {
+	if concurrentLevels {
+		var wg sync.WaitGroup
+		for _, level := range levels {
+			wg.Add(1)
+			// 每个优先级使用各自的 Context 副本匹配，匹配过程中写入的 RegexResult 互不影响
+			go func(levelCtx *Context, level []*Matcher) {
+				defer wg.Done()
+				runLevel(levelCtx, level, admitted)
+			}(ctx.forMatcher(nil), level)
+		}
+		wg.Wait()
+		return
+	}
+
+	for _, level := range levels {
+		// 处理函数调用 Next() 才继续执行更低优先级的匹配器
+		ctx.shared.shouldContinue.Store(false)
+
+		matched, blocked := runLevel(ctx, level, admitted)
+		if !matched {
+			continue
+		}
+		if blocked || ctx.IsAborted() || !ctx.shared.shouldContinue.Load() {
+			return
+		}
+	}
+}
+
+// runLevel 依次匹配并执行同一优先级的匹配器，每个处理函数返回后才匹配下一个
+// 处理函数调用 Abort() 或匹配器设置了 SetBlock 时，同一优先级的后续匹配器不再执行
+func runLevel(ctx *Context, level []*Matcher, admitted map[*Engine]bool) (matched, blocked bool) {
+	for _, matcher := range level {
+		if ctx.IsAborted() {
+			return matched, false
+		}
+		if !admitted[matcher.engine] {
+			continue
+		}
+		handlerCtx, ok := matchOne(ctx, matcher)
+		if !ok {
+			continue
+		}
+		matched = true
+		runMatcher(handlerCtx, matcher)
+		if matcher.block {
+			return true, true
+		}
+	}
+	return matched, false
+}
+
+// matchOne 判断匹配器是否匹配并记录追踪和指标
+// 匹配成功时返回交给处理函数的 Context 副本，副本保存了本次匹配的正则结果
+func matchOne(ctx *Context, matcher *Matcher) (*Context, bool) {
+	ctx.RegexResult = nil
+
+	matchSpan := ctx.Span.StartChild("matcher.match")
+	matched := matcher.Match(ctx)
+	matchSpan.SetAttribute("matcher", matcher.name).SetAttribute("matched", matched)
+	matchSpan.End()
+
+	if !matched {
+		return nil, false
+	}
+	metrics.MatcherHits.Inc(matcher.name)
+	ctx.shared.matched.Store(true)
+	return ctx.forMatcher(ctx.RegexResult), true
+}
+
+// runMatcher 执行匹配器的处理函数，处理函数由所属引擎的中间件（Engine.Use）包裹
+func runMatcher(ctx *Context, m *Matcher) {
+	start := time.Now()
+	metrics.HandlersInFlight.Inc()
+	execSpan := ctx.Span.StartChild("matcher.execute")
+	execSpan.SetAttribute("matcher", m.name)
+	defer func() {
+		metrics.HandlersInFlight.Dec()
+		metrics.HandlerDuration.Observe(time.Since(start).Seconds(), m.name)
+		if r := recover(); r != nil {
+			execSpan.SetStatus(trace.StatusError, fmt.Sprint(r))
+			m.handleError(ctx, newPanicError(r))
+		}
+		execSpan.End()
+	}()
+
+	handler := m.Execute
+	if e := m.engine; e != nil {
+		e.mu.RLock()
+		mws := e.middlewares
+		e.mu.RUnlock()
+		for i := len(mws) - 1; i >= 0; i-- {
+			handler = mws[i](handler)
+		}
+	}
+	handler(ctx)
+}
+
+// ========== 匹配器排序缓存 ==========
+
+// matchersVersion 匹配器列表版本，添加、移除匹配器或修改优先级时递增，用于使排序缓存失效
+var matchersVersion atomic.Int64
+
+// matcherIndex 某个版本下按优先级排序的匹配器
+type matcherIndex struct {
+	version  int64
+	matchers []*Matcher   // 按优先级从高到低排序
+	levels   [][]*Matcher // 按优先级分组，顺序执行模式使用
+}
+
+// matcherCache 按优先级排序的匹配器缓存，避免每个事件都复制并排序所有匹配器
+type matcherCache struct {
+	index atomic.Pointer[matcherIndex]
+}
+
+// sorted 获取引擎的所有匹配器，按优先级从高到低排序
+func (c *matcherCache) sorted(engines []*Engine) []*Matcher {
+	return c.get(engines).matchers
+}
+
+// get 获取引擎的所有匹配器的排序结果
+// 优先级相同时按引擎注册顺序和匹配器注册顺序排列；引擎和匹配器是否启用在分发时检查
+func (c *matcherCache) get(engines []*Engine) *matcherIndex {
+	version := matchersVersion.Load()
+	if idx := c.index.Load(); idx != nil && idx.version == version {
+		return idx
+	}
+
+	var matchers []*Matcher
+	for _, e := range engines {
+		matchers = append(matchers, e.snapshot()...)
+	}
+	priorities := sortByPriority(matchers)
+
+	// 分组使用排序时读取的优先级，排序后修改的优先级在下次分发时生效
+	idx := &matcherIndex{version: version, matchers: matchers}
+	for start := 0; start < len(matchers); {
+		end := start
+		for end < len(matchers) && priorities[end] == priorities[start] {
+			end++
+		}
+		idx.levels = append(idx.levels, matchers[start:end])
+		start = end
+	}
+
+	// 排序期间版本变化时缓存的是旧版本，下次分发会重新排序
+	c.index.Store(idx)
+	return idx
+}
+
+// sortByPriority 按优先级从高到低稳定排序匹配器，返回排序后每个匹配器的优先级
+// 每个匹配器的优先级只读取一次，排序期间其他 goroutine 修改优先级不会使比较结果前后矛盾
+func sortByPriority(matchers []*Matcher) []int64 {
+	type entry struct {
+		matcher  *Matcher
+		priority int64
+	}
+	entries := make([]entry, len(matchers))
+	for i, m := range matchers {
+		entries[i] = entry{m, m.priority.Load()}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].priority > entries[j].priority
+	})
+
+	priorities := make([]int64, len(entries))
+	for i, e := range entries {
+		matchers[i] = e.matcher
+		priorities[i] = e.priority
+	}
+	return priorities
+}
@@ -0,0 +1,314 @@
+package xbot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestDispatchGlobalPriority 测试跨引擎的全局优先级、阻止匹配和共享 Context
+func TestDispatchGlobalPriority(t *testing.T) {
+	bot := &Bot{Config: &Config{}}
+	a := &Engine{name: "a", bot: bot}
+	b := &Engine{name: "b", bot: bot}
+
+	ran := make(chan string, 4)
+	contexts := make(chan *Context, 4)
+	a.OnKeywords([]string{"hi"}).Handle(func(ctx *Context) {
+		ran <- "a-low"
+	})
+	b.OnKeywords([]string{"hi"}).Priority(10).SetBlock().Handle(func(ctx *Context) {
+		contexts <- ctx
+		ran <- "b-high"
+	})
+	a.OnKeywords([]string{"hi"}).Priority(20).Handle(func(ctx *Context) {
+		contexts <- ctx
+		ran <- "a-top"
+	})
+
+	cache := &matcherCache{}
+	if got := cache.sorted([]*Engine{a, b}); got[0].engine != a || got[1].engine != b || got[2].engine != a {
+		t.Fatalf("Unexpected matcher order")
+	}
+
+	dispatch(NewContext(&event.GroupMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		ParsedMessage: message.Message{message.Text("hi")},
+	}, bot), []*Engine{a, b}, cache)
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-ran:
+			got[name] = true
+		case <-time.After(time.Second):
+			t.Fatal("Expected 2 handlers to run")
+		}
+	}
+	if !got["a-top"] || !got["b-high"] {
+		t.Errorf("Expected top and blocking handlers to run, got %v", got)
+	}
+	select {
+	case name := <-ran:
+		t.Errorf("Expected lower priority handler in other engine to be blocked, %s ran", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if (<-contexts).shared != (<-contexts).shared {
+		t.Error("Expected handlers in different engines to share the Context state")
+	}
+}
+
+// TestMatcherCache 测试排序缓存在添加匹配器和修改优先级后失效
+func TestMatcherCache(t *testing.T) {
+	e := &Engine{name: "cache"}
+	cache := &matcherCache{}
+	low := e.OnMessage()
+	high := e.OnMessage().Priority(5)
+
+	if got := cache.sorted([]*Engine{e}); len(got) != 2 || got[0] != high {
+		t.Fatalf("Unexpected order %v", got)
+	}
+	if a, b := cache.sorted([]*Engine{e}), cache.sorted([]*Engine{e}); &a[0] != &b[0] {
+		t.Error("Expected sorted matchers to be cached")
+	}
+
+	low.Priority(10)
+	if got := cache.sorted([]*Engine{e}); got[0] != low {
+		t.Error("Expected priority change to invalidate the cache")
+	}
+	added := e.OnMessage().Priority(20)
+	if got := cache.sorted([]*Engine{e}); len(got) != 3 || got[0] != added {
+		t.Error("Expected new matcher to invalidate the cache")
+	}
+}
+
+// TestPriorityDuringDispatch 测试分发排序时并发修改优先级不产生数据竞争
+func TestPriorityDuringDispatch(t *testing.T) {
+	e := &Engine{name: "priority"}
+	cache := &matcherCache{}
+	m := e.OnMessage()
+	e.OnMessage().Priority(1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			m.Priority(i % 3)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			cache.get([]*Engine{e})
+		}
+	}()
+	wg.Wait()
+
+	m.Priority(5)
+	if idx := cache.get([]*Engine{e}); idx.matchers[0] != m || len(idx.levels) != 2 {
+		t.Errorf("Unexpected order after priority change: %v", idx.levels)
+	}
+}
+
+// TestEngineEventMiddleware 测试引擎事件中间件每个事件执行一次，不调用 next 时该引擎不参与匹配
+func TestEngineEventMiddleware(t *testing.T) {
+	bot := &Bot{Config: &Config{DispatchMode: DispatchSequential}}
+	a := &Engine{name: "a", bot: bot}
+	b := &Engine{name: "b", bot: bot}
+
+	calls := 0
+	a.UseEvent(func(next func(*Context)) func(*Context) {
+		return func(ctx *Context) {
+			calls++
+			if ctx.GetPlainText() != "drop" {
+				next(ctx)
+			}
+		}
+	})
+
+	var ran []string
+	a.OnMessage().Priority(10).SetBlock().Handle(func(ctx *Context) { ran = append(ran, "a") })
+	b.OnMessage().Handle(func(ctx *Context) { ran = append(ran, "b") })
+	b.OnFullMatch("never").Handle(func(ctx *Context) {})
+
+	send := func(text string) []string {
+		ran = nil
+		dispatch(NewContext(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			ParsedMessage: message.Message{message.Text(text)},
+		}, bot), []*Engine{a, b}, &matcherCache{})
+		return ran
+	}
+
+	if got := send("hi"); len(got) != 1 || got[0] != "a" {
+		t.Errorf("Expected blocking matcher in a to stop b, got %v", got)
+	}
+	if got := send("drop"); len(got) != 1 || got[0] != "b" {
+		t.Errorf("Expected dropped engine not to block b, got %v", got)
+	}
+	if calls != 2 {
+		t.Errorf("Expected middleware to run once per event, got %d", calls)
+	}
+}
+
+// TestEngineMiddleware 测试引擎中间件包裹每个匹配成功的处理函数，能够捕获处理函数的 panic
+func TestEngineMiddleware(t *testing.T) {
+	bot := &Bot{Config: &Config{DispatchMode: DispatchSequential}}
+	e := &Engine{name: "mw", bot: bot}
+
+	var wrapped []string
+	recovered := 0
+	e.Use(func(next func(*Context)) func(*Context) {
+		return func(ctx *Context) {
+			defer func() {
+				if recover() != nil {
+					recovered++
+				}
+			}()
+			wrapped = append(wrapped, ctx.GetPlainText())
+			next(ctx)
+		}
+	})
+	e.OnFullMatch("hi").Handle(func(ctx *Context) {})
+	e.OnFullMatch("hi").Handle(func(ctx *Context) { panic("boom") })
+
+	for _, text := range []string{"hi", "other"} {
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			ParsedMessage: message.Message{message.Text(text)},
+		})
+	}
+	if len(wrapped) != 2 || recovered != 1 {
+		t.Errorf("Expected middleware to wrap 2 handlers and recover 1 panic, got %v and %d", wrapped, recovered)
+	}
+}
+
+// TestRegexResultPerMatcher 测试每个处理函数拿到自己匹配器的正则结果
+func TestRegexResultPerMatcher(t *testing.T) {
+	bot := &Bot{Config: &Config{}}
+	e := &Engine{name: "regex", bot: bot}
+
+	results := make(chan string, 2)
+	release := make(chan struct{})
+	e.OnRegex(`^(\w+) (\w+)$`).Priority(10).Handle(func(ctx *Context, m *RegexMatch) {
+		<-release // 等待后续匹配器完成匹配
+		results <- "first:" + m.Groups[1]
+	})
+	e.OnRegex(`(\w+)$`).Handle(func(ctx *Context, m *RegexMatch) {
+		results <- "second:" + m.Groups[1]
+		close(release)
+	})
+
+	e.HandleEvent(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		ParsedMessage: message.Message{message.Text("hello world")},
+	})
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-results:
+			got[r] = true
+		case <-time.After(time.Second):
+			t.Fatal("Expected both handlers to run")
+		}
+	}
+	if !got["first:hello"] || !got["second:world"] {
+		t.Errorf("Expected each handler to see its own regex result, got %v", got)
+	}
+}
+
+// TestDispatchSequential 测试顺序执行模式下 Next 和 Abort 决定是否继续执行
+func TestDispatchSequential(t *testing.T) {
+	bot := &Bot{Config: &Config{DispatchMode: DispatchSequential}}
+	e := &Engine{name: "seq", bot: bot}
+
+	var ran []string
+	handle := func(name string, priority int, fn func(ctx *Context)) {
+		e.OnKeywords([]string{"hi", "stop"}).Priority(priority).Handle(func(ctx *Context) {
+			ran = append(ran, name)
+			fn(ctx)
+		})
+	}
+	handle("p10", 10, func(ctx *Context) { ctx.Next() })
+	handle("p5-abort", 5, func(ctx *Context) {
+		if ctx.GetPlainText() == "stop" {
+			ctx.Abort()
+		}
+	})
+	handle("p5", 5, func(ctx *Context) {})
+	handle("p1", 1, func(ctx *Context) {})
+
+	send := func(text string) []string {
+		ran = nil
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			ParsedMessage: message.Message{message.Text(text)},
+		})
+		return ran
+	}
+
+	// p10 调用 Next 继续；p5 级别没有调用 Next，p1 不执行
+	if got := send("hi"); len(got) != 3 || got[2] != "p5" {
+		t.Errorf("Expected [p10 p5-abort p5], got %v", got)
+	}
+	// Abort 后同一优先级的后续匹配器也不执行
+	if got := send("stop"); len(got) != 2 || got[1] != "p5-abort" {
+		t.Errorf("Expected [p10 p5-abort], got %v", got)
+	}
+}
+
+// TestDispatchConcurrentLevels 测试顺序模式下开启 ConcurrentLevels 后各优先级并发执行、优先级内依次执行
+func TestDispatchConcurrentLevels(t *testing.T) {
+	bot := &Bot{Config: &Config{DispatchMode: DispatchSequential, ConcurrentLevels: true}}
+	e := &Engine{name: "levels", bot: bot}
+
+	var mu sync.Mutex
+	var ran []string
+	record := func(name string) {
+		mu.Lock()
+		ran = append(ran, name)
+		mu.Unlock()
+	}
+
+	// 高优先级等待低优先级执行后才返回，只有优先级之间并发执行才不会超时
+	lowDone := make(chan struct{})
+	e.OnRegex(`^hi (\w+)$`).Priority(10).Handle(func(ctx *Context) {
+		select {
+		case <-lowDone:
+			record("p10:" + ctx.RegexResult.Groups[1])
+		case <-time.After(time.Second):
+			record("p10:timeout")
+		}
+	})
+	e.OnRegex(`^(hi) \w+$`).Priority(1).Handle(func(ctx *Context) {
+		record("p1-first:" + ctx.RegexResult.Groups[1])
+	})
+	e.OnRegex(`^hi (\w+)$`).Priority(1).Handle(func(ctx *Context) {
+		record("p1-second:" + ctx.RegexResult.Groups[1])
+		close(lowDone)
+	})
+
+	e.HandleEvent(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		ParsedMessage: message.Message{message.Text("hi there")},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"p1-first:hi", "p1-second:there", "p10:there"}
+	if len(ran) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, ran)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, ran)
+			break
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package driver
 
 import (
+	"errors"
+	"sync/atomic"
+
 	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/types"
 )
 
@@ -23,6 +27,15 @@ type Driver interface {
 	IsConnected() bool
 }
 
+// 驱动器返回的常见错误，可通过 errors.Is 判断
+var (
+	// ErrNotConnected 未连接，请求没有发送到 OneBot，重试是安全的
+	ErrNotConnected = errors.New("未连接")
+
+	// ErrTimeout API 调用超时，请求可能已经被 OneBot 执行
+	ErrTimeout = errors.New("API 调用超时")
+)
+
 // EventHandler 事件处理器
 type EventHandler func(event event.Event)
 
@@ -38,3 +51,24 @@ type Config struct {
 	HeartbeatInterval int    // 心跳间隔（秒）
 	Timeout           int    // API 调用超时（秒）
 }
+
+// moduleLog 缓存的驱动器模块日志记录器
+type moduleLog struct {
+	gen uint64        // 创建时默认日志记录器的版本
+	log logger.Logger // 带模块字段的日志记录器
+}
+
+// driverLog 驱动器模块日志记录器，只在默认日志记录器被替换（SetDefault）后重新创建
+var driverLog atomic.Pointer[moduleLog]
+
+// log 获取驱动器模块的日志记录器
+// 可通过配置 log.levels.driver 单独调整驱动器日志级别
+func log() logger.Logger {
+	base, gen := logger.DefaultGeneration()
+	if c := driverLog.Load(); c != nil && c.gen == gen {
+		return c.log
+	}
+	c := &moduleLog{gen: gen, log: base.WithField(logger.ModuleField, "driver")}
+	driverLog.Store(c)
+	return c.log
+}
@@ -9,7 +9,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/types"
 )
 
@@ -50,10 +49,10 @@ func (d *HTTPDriver) Connect() error {
 	// 尝试调用 get_version 测试连接
 	_, err := d.CallAPI("get_version", nil)
 	if err != nil {
-		logger.Warn("HTTP 驱动器连接测试失败", "error", err, "url", baseURL)
+		log().Warn("HTTP 驱动器连接测试失败", "error", err, "url", baseURL)
 		// 不返回错误，因为 HTTP 驱动器可以在后续调用时再连接
 	} else {
-		logger.Info("HTTP 驱动器连接成功", "url", baseURL)
+		log().Info("HTTP 驱动器连接成功", "url", baseURL)
 	}
 
 	d.mu.Lock()
@@ -124,7 +123,7 @@ func (d *HTTPDriver) CallAPI(action string, params map[string]interface{}) (*typ
 // 注意：HTTP 驱动器不会接收事件推送
 func (d *HTTPDriver) SetEventHandler(handler EventHandler) {
 	d.eventHandler = handler
-	logger.Warn("HTTP 驱动器不支持接收事件推送，事件处理器将不会被调用")
+	log().Warn("HTTP 驱动器不支持接收事件推送，事件处理器将不会被调用")
 }
 
 // Close 关闭连接
@@ -12,7 +12,6 @@ import (
 	"time"
 
 	"github.com/xiaoyi510/xbot/event"
-	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/types"
 	"github.com/xiaoyi510/xbot/utils"
 )
@@ -75,9 +74,9 @@ func (d *HTTPPostDriver) Connect() error {
 
 	// 启动 HTTP 服务器
 	go func() {
-		logger.Info("反向 HTTP 服务器启动", "addr", addr)
+		log().Info("反向 HTTP 服务器启动", "addr", addr)
 		if err := d.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("HTTP 服务器启动失败", "error", err)
+			log().Error("HTTP 服务器启动失败", "error", err)
 			d.mu.Lock()
 			d.connected = false
 			d.mu.Unlock()
@@ -94,7 +93,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("Authorization")
 		expectedToken := "Bearer " + d.config.AccessToken
 		if token != expectedToken {
-			logger.Warn("无效的 Access Token", "token", token)
+			log().Warn("无效的 Access Token", "token", token)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -109,7 +108,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 	// 读取请求体
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		logger.Error("读取请求体失败", "error", err)
+		log().Error("读取请求体失败", "error", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -121,7 +120,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.Unmarshal(body, &base); err != nil {
-		logger.Error("解析消息失败", "error", err)
+		log().Error("解析消息失败", "error", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -130,7 +129,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 	if base.Echo != "" {
 		var resp types.APIResponse
 		if err := json.Unmarshal(body, &resp); err != nil {
-			logger.Error("解析 API 响应失败", "error", err)
+			log().Error("解析 API 响应失败", "error", err)
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
@@ -140,7 +139,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 			select {
 			case ch <- &resp:
 			case <-time.After(time.Second):
-				logger.Warn("API 响应通道阻塞", "echo", base.Echo)
+				log().Warn("API 响应通道阻塞", "echo", base.Echo)
 			}
 			d.apiResponses.Delete(base.Echo)
 		}
@@ -153,7 +152,7 @@ func (d *HTTPPostDriver) handleEvent(w http.ResponseWriter, r *http.Request) {
 	// 否则是事件
 	evt, err := event.ParseEvent(body)
 	if err != nil {
-		logger.Error("解析事件失败", "error", err)
+		log().Error("解析事件失败", "error", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
@@ -241,7 +240,7 @@ func (d *HTTPPostDriver) CallAPI(action string, params map[string]interface{}) (
 		return apiResp, nil
 	case <-time.After(timeout):
 		d.apiResponses.Delete(echo)
-		return nil, errors.New("API 调用超时")
+		return nil, ErrTimeout
 	}
 }
 
@@ -263,7 +262,7 @@ func (d *HTTPPostDriver) Close() error {
 		defer cancel()
 
 		if err := d.server.Shutdown(ctx); err != nil {
-			logger.Error("关闭 HTTP 服务器失败", "error", err)
+			log().Error("关闭 HTTP 服务器失败", "error", err)
 			return err
 		}
 		d.server = nil
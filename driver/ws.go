@@ -2,13 +2,12 @@ package driver
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/xiaoyi510/xbot/event"
-	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/metrics"
 	"github.com/xiaoyi510/xbot/types"
 	"github.com/xiaoyi510/xbot/utils"
 
@@ -68,7 +67,7 @@ func (d *WebSocketDriver) Connect() error {
 	d.connected = true
 	d.mu.Unlock()
 
-	logger.Info("正向 WebSocket 连接成功", "url", wsURL)
+	log().Info("正向 WebSocket 连接成功", "url", wsURL)
 
 	// 启动消息接收协程
 	go d.receiveMessages()
@@ -98,7 +97,7 @@ func (d *WebSocketDriver) receiveMessages() {
 
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			logger.Warn("接收 WebSocket 消息失败", "error", err)
+			log().Warn("接收 WebSocket 消息失败", "error", err)
 			d.mu.Lock()
 			d.connected = false
 			d.mu.Unlock()
@@ -118,7 +117,7 @@ func (d *WebSocketDriver) handleMessage(data []byte) {
 	}
 
 	if err := json.Unmarshal(data, &base); err != nil {
-		logger.Error("解析消息失败", "error", err)
+		log().Error("解析消息失败", "error", err)
 		return
 	}
 
@@ -126,7 +125,7 @@ func (d *WebSocketDriver) handleMessage(data []byte) {
 	if base.Echo != "" {
 		var resp types.APIResponse
 		if err := json.Unmarshal(data, &resp); err != nil {
-			logger.Error("解析 API 响应失败", "error", err)
+			log().Error("解析 API 响应失败", "error", err)
 			return
 		}
 
@@ -135,7 +134,7 @@ func (d *WebSocketDriver) handleMessage(data []byte) {
 			select {
 			case ch <- &resp:
 			case <-time.After(time.Second):
-				logger.Warn("API 响应通道阻塞", "echo", base.Echo)
+				log().Warn("API 响应通道阻塞", "echo", base.Echo)
 			}
 			d.apiResponses.Delete(base.Echo)
 		}
@@ -145,7 +144,7 @@ func (d *WebSocketDriver) handleMessage(data []byte) {
 	// 否则是事件
 	evt, err := event.ParseEvent(data)
 	if err != nil {
-		logger.Error("解析事件失败", "error", err)
+		log().Error("解析事件失败", "error", err)
 		return
 	}
 
@@ -163,7 +162,7 @@ func (d *WebSocketDriver) CallAPI(action string, params map[string]interface{})
 	d.mu.RUnlock()
 
 	if !connected || conn == nil {
-		return nil, errors.New("WebSocket 未连接")
+		return nil, fmt.Errorf("WebSocket %w", ErrNotConnected)
 	}
 
 	// 生成 echo
@@ -203,7 +202,7 @@ func (d *WebSocketDriver) CallAPI(action string, params map[string]interface{})
 		return resp, nil
 	case <-time.After(timeout):
 		d.apiResponses.Delete(echo)
-		return nil, errors.New("API 调用超时")
+		return nil, ErrTimeout
 	}
 }
 
@@ -254,7 +253,7 @@ func (d *WebSocketDriver) heartbeatLoop() {
 				if conn != nil {
 					// 发送 Ping 消息
 					if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
-						logger.Warn("发送心跳失败", "error", err)
+						log().Warn("发送心跳失败", "error", err)
 						d.mu.Lock()
 						d.connected = false
 						d.mu.Unlock()
@@ -279,13 +278,14 @@ func (d *WebSocketDriver) reconnectLoop() {
 			if !d.IsConnected() {
 				// 检查是否超过最大重连次数
 				if d.config.MaxReconnect > 0 && reconnectCount >= d.config.MaxReconnect {
-					logger.Error("达到最大重连次数", "count", reconnectCount)
+					log().Error("达到最大重连次数", "count", reconnectCount)
 					return
 				}
 
-				logger.Info("尝试重新连接 WebSocket", "attempt", reconnectCount+1)
+				metrics.DriverReconnects.Inc(d.config.Type)
+				log().Info("尝试重新连接 WebSocket", "attempt", reconnectCount+1)
 				if err := d.Connect(); err != nil {
-					logger.Error("WebSocket 重连失败", "error", err)
+					log().Error("WebSocket 重连失败", "error", err)
 					reconnectCount++
 				} else {
 					reconnectCount = 0
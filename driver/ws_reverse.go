@@ -2,13 +2,12 @@ package driver
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/xiaoyi510/xbot/event"
-	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/metrics"
 	"github.com/xiaoyi510/xbot/types"
 	"github.com/xiaoyi510/xbot/utils"
 
@@ -59,7 +58,7 @@ func (d *WSReverseDriver) Connect() error {
 	d.connected = true
 	d.mu.Unlock()
 
-	logger.Info("WebSocket 连接成功", "url", d.config.URL)
+	log().Info("WebSocket 连接成功", "url", d.config.URL)
 
 	// 启动消息接收协程
 	go d.receiveMessages()
@@ -84,7 +83,7 @@ func (d *WSReverseDriver) receiveMessages() {
 
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			logger.Warn("接收消息失败", "error", err)
+			log().Warn("接收消息失败", "error", err)
 			d.mu.Lock()
 			d.connected = false
 			d.mu.Unlock()
@@ -104,7 +103,7 @@ func (d *WSReverseDriver) handleMessage(data []byte) {
 	}
 
 	if err := json.Unmarshal(data, &base); err != nil {
-		logger.Error("解析消息失败", "error", err)
+		log().Error("解析消息失败", "error", err)
 		return
 	}
 
@@ -112,7 +111,7 @@ func (d *WSReverseDriver) handleMessage(data []byte) {
 	if base.Echo != "" {
 		var resp types.APIResponse
 		if err := json.Unmarshal(data, &resp); err != nil {
-			logger.Error("解析 API 响应失败", "error", err)
+			log().Error("解析 API 响应失败", "error", err)
 			return
 		}
 
@@ -121,7 +120,7 @@ func (d *WSReverseDriver) handleMessage(data []byte) {
 			select {
 			case ch <- &resp:
 			case <-time.After(time.Second):
-				logger.Warn("API 响应通道阻塞", "echo", base.Echo)
+				log().Warn("API 响应通道阻塞", "echo", base.Echo)
 			}
 			d.apiResponses.Delete(base.Echo)
 		}
@@ -131,7 +130,7 @@ func (d *WSReverseDriver) handleMessage(data []byte) {
 	// 否则是事件
 	evt, err := event.ParseEvent(data)
 	if err != nil {
-		logger.Error("解析事件失败", "error", err)
+		log().Error("解析事件失败", "error", err)
 		return
 	}
 
@@ -149,7 +148,7 @@ func (d *WSReverseDriver) CallAPI(action string, params map[string]interface{})
 	d.mu.RUnlock()
 
 	if !connected || conn == nil {
-		return nil, errors.New("未连接")
+		return nil, ErrNotConnected
 	}
 
 	// 生成 echo
@@ -185,7 +184,7 @@ func (d *WSReverseDriver) CallAPI(action string, params map[string]interface{})
 		return resp, nil
 	case <-time.After(timeout):
 		d.apiResponses.Delete(echo)
-		return nil, errors.New("API 调用超时")
+		return nil, ErrTimeout
 	}
 }
 
@@ -232,13 +231,14 @@ func (d *WSReverseDriver) reconnectLoop() {
 			if !d.IsConnected() {
 				// 检查是否超过最大重连次数
 				if d.config.MaxReconnect > 0 && reconnectCount >= d.config.MaxReconnect {
-					logger.Error("达到最大重连次数", "count", reconnectCount)
+					log().Error("达到最大重连次数", "count", reconnectCount)
 					return
 				}
 
-				logger.Info("尝试重新连接", "attempt", reconnectCount+1)
+				metrics.DriverReconnects.Inc(d.config.Type)
+				log().Info("尝试重新连接", "attempt", reconnectCount+1)
 				if err := d.Connect(); err != nil {
-					logger.Error("重连失败", "error", err)
+					log().Error("重连失败", "error", err)
 					reconnectCount++
 				} else {
 					reconnectCount = 0
@@ -1,21 +1,31 @@
 package xbot
 
 import (
-	"sort"
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xiaoyi510/xbot/event"
-	"github.com/xiaoyi510/xbot/logger"
 	"github.com/xiaoyi510/xbot/middleware"
+	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/types"
 )
 
 // Engine 引擎
 type Engine struct {
-	matchers    []*Matcher
-	mu          sync.RWMutex
-	bot         *Bot
-	middlewares []func(next func(*Context)) func(*Context)
+	name             string      // 引擎名称，通常为插件名
+	disabled         atomic.Bool // 是否已禁用，禁用后不再处理事件
+	matchers         []*Matcher
+	mu               sync.RWMutex
+	bot              *Bot
+	middlewares      []func(next func(*Context)) func(*Context) // 包裹每个处理函数
+	eventMiddlewares []func(next func(*Context)) func(*Context) // 包裹每个事件的匹配与分发
+
+	errorHandlers []ErrorHandler // 处理函数出错时调用
+	matcherCache  matcherCache   // 单独分发该引擎时使用的排序缓存
+	dialogs       []*Dialog      // 引擎中创建的对话，重启后恢复定时器
 }
 
 // NewEngine 创建引擎
@@ -31,7 +41,49 @@ func NewEngine() *Engine {
 	return engine
 }
 
-// Use 添加全局中间件
+// Name 设置引擎名称
+// 名称用于管理后台启用/禁用插件，默认为 "engine-<序号>"
+func (e *Engine) Name(name string) *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.name = name
+	return e
+}
+
+// GetName 获取引擎名称
+func (e *Engine) GetName() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.name
+}
+
+// Enable 启用引擎
+func (e *Engine) Enable() *Engine {
+	e.disabled.Store(false)
+	return e
+}
+
+// Disable 禁用引擎，禁用期间所有匹配器都不会被触发
+func (e *Engine) Disable() *Engine {
+	e.disabled.Store(true)
+	return e
+}
+
+// IsEnabled 引擎是否启用
+func (e *Engine) IsEnabled() bool {
+	return !e.disabled.Load()
+}
+
+// Matchers 获取引擎的所有匹配器（按优先级排序）
+func (e *Engine) Matchers() []*Matcher {
+	matchers := append([]*Matcher(nil), e.snapshot()...)
+	sortByPriority(matchers)
+	return matchers
+}
+
+// Use 添加引擎中间件
+// 引擎中间件包裹本引擎每个匹配成功的处理函数，在匹配器中间件之外执行，
+// 与处理函数在同一个 goroutine 中运行，因此 UseRecovery、UseLogger、UseMetrics 能覆盖处理函数
 func (e *Engine) Use(middlewares ...func(next func(*Context)) func(*Context)) *Engine {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -39,6 +91,16 @@ func (e *Engine) Use(middlewares ...func(next func(*Context)) func(*Context)) *E
 	return e
 }
 
+// UseEvent 添加引擎事件中间件
+// 事件中间件包裹本引擎对每个事件的匹配与分发过程，每个事件执行一次（不论是否有匹配器匹配）；
+// 不调用 next 时本引擎的匹配器不参与该事件的匹配，也不会阻止其他引擎
+func (e *Engine) UseEvent(middlewares ...func(next func(*Context)) func(*Context)) *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.eventMiddlewares = append(e.eventMiddlewares, middlewares...)
+	return e
+}
+
 // OnCommand 命令匹配
 func (e *Engine) OnCommand(command string, filters ...Filter) *Matcher {
 	prefix := ""
@@ -48,7 +110,7 @@ func (e *Engine) OnCommand(command string, filters ...Filter) *Matcher {
 		prefix = "/" // 默认前缀
 	}
 
-	matcher := newMatcher(commandMatcher(command, prefix), filters...)
+	matcher := newMatcher("command:"+command, commandMatcher(command, prefix), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
@@ -62,42 +124,51 @@ func (e *Engine) OnCommandGroup(commands []string, filters ...Filter) *Matcher {
 		prefix = "/"
 	}
 
-	matcher := newMatcher(commandGroupMatcher(commands, prefix), filters...)
+	matcher := newPatternMatcher("command_group", strings.Join(commands, "|"), commandGroupMatcher(commands, prefix), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
+// newPatternMatcher 创建按内容匹配的匹配器
+// 匹配内容由用户决定，长度和数量没有上限，因此名称使用 kind 加匹配器 ID，匹配内容单独保存用于日志
+func newPatternMatcher(kind, pattern string, matchFunc func(*Context) bool, filters ...Filter) *Matcher {
+	matcher := newMatcher(kind, matchFunc, filters...)
+	matcher.name = fmt.Sprintf("%s#%d", kind, matcher.id)
+	matcher.pattern = pattern
+	return matcher
+}
+
 // OnKeywords 关键词匹配
 func (e *Engine) OnKeywords(keywords []string, filters ...Filter) *Matcher {
-	matcher := newMatcher(keywordsMatcher(keywords), filters...)
+	matcher := newPatternMatcher("keywords", strings.Join(keywords, "|"), keywordsMatcher(keywords), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
 // OnRegex 正则表达式匹配
 func (e *Engine) OnRegex(pattern string, filters ...Filter) *Matcher {
-	matcher := newMatcher(regexMatcher(pattern), filters...)
+	matcher := newPatternMatcher("regex", pattern, regexMatcher(pattern), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
 // OnPrefix 前缀匹配
 func (e *Engine) OnPrefix(prefix string, filters ...Filter) *Matcher {
-	matcher := newMatcher(prefixMatcher(prefix), filters...)
+	matcher := newPatternMatcher("prefix", prefix, prefixMatcher(prefix), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
 // OnSuffix 后缀匹配
 func (e *Engine) OnSuffix(suffix string, filters ...Filter) *Matcher {
-	matcher := newMatcher(suffixMatcher(suffix), filters...)
+	matcher := newPatternMatcher("suffix", suffix, suffixMatcher(suffix), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
 // OnFullMatch 完全匹配
 func (e *Engine) OnFullMatch(text string, filters ...Filter) *Matcher {
-	matcher := newMatcher(fullMatchMatcher(text), filters...)
+	matcher := newPatternMatcher("full_match", text, fullMatchMatcher(text), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
@@ -109,7 +180,7 @@ func (e *Engine) OnFullMatch(text string, filters ...Filter) *Matcher {
 //
 // 推荐使用 NewKeywordManager 或 NewAtomicKeywordManager 创建关键词管理器
 func (e *Engine) OnDFAKeywords(provider VersionedKeywordProvider, filters ...Filter) *Matcher {
-	matcher := newMatcher(dfaMatcher(provider), filters...)
+	matcher := newMatcher("dfa_keywords", dfaMatcher(provider), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
@@ -120,7 +191,7 @@ func (e *Engine) OnDFAKeywords(provider VersionedKeywordProvider, filters ...Fil
 // 根据上下文（如群ID）动态选择不同的关键词库
 // 适用场景：不同群组使用不同的敏感词库
 func (e *Engine) OnDFAKeywordsWithContext(provider ContextKeywordProvider, filters ...Filter) *Matcher {
-	matcher := newMatcher(dfaMatcherWithContext(provider), filters...)
+	matcher := newMatcher("dfa_keywords_context", dfaMatcherWithContext(provider), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
@@ -133,7 +204,7 @@ func (e *Engine) OnDFAKeywordsWithContext(provider ContextKeywordProvider, filte
 //
 // 使用版本号检测变化，O(1) 性能，零内存分配
 func (e *Engine) OnACKeywords(provider VersionedKeywordProvider, filters ...Filter) *Matcher {
-	matcher := newMatcher(acMatcher(provider), filters...)
+	matcher := newMatcher("ac_keywords", acMatcher(provider), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
@@ -145,14 +216,14 @@ func (e *Engine) OnACKeywords(provider VersionedKeywordProvider, filters ...Filt
 // 根据上下文（如群ID）动态选择不同的关键词库
 // 适用场景：不同群组使用不同的敏感词库，且关键词数量较多
 func (e *Engine) OnACKeywordsWithContext(provider ContextKeywordProvider, filters ...Filter) *Matcher {
-	matcher := newMatcher(acMatcherWithContext(provider), filters...)
+	matcher := newMatcher("ac_keywords_context", acMatcherWithContext(provider), filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
 // OnMessage 消息事件
 func (e *Engine) OnMessage(filters ...Filter) *Matcher {
-	matcher := newMatcher(func(ctx *Context) bool {
+	matcher := newMatcher("message", func(ctx *Context) bool {
 		_, isPrivate := ctx.Event.(*event.PrivateMessageEvent)
 		_, isGroup := ctx.Event.(*event.GroupMessageEvent)
 		return isPrivate || isGroup
@@ -163,92 +234,142 @@ func (e *Engine) OnMessage(filters ...Filter) *Matcher {
 
 // OnNotice 通知事件
 func (e *Engine) OnNotice(filters ...Filter) *Matcher {
-	matcher := newMatcher(func(ctx *Context) bool {
+	matcher := newMatcher("notice", func(ctx *Context) bool {
 		return ctx.Event.GetPostType() == "notice"
 	}, filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
+// OnMessageSent 机器人自身发送的消息事件（需要 OneBot 实现上报 message_sent）
+// 处理函数可通过 ctx.Event.(*event.MessageSentEvent) 获取消息
+func (e *Engine) OnMessageSent(filters ...Filter) *Matcher {
+	matcher := newMatcher("message_sent", func(ctx *Context) bool {
+		_, ok := ctx.Event.(*event.MessageSentEvent)
+		return ok
+	}, filters...)
+	e.addMatcher(matcher)
+	return matcher
+}
+
+// OnNoticeType 指定类型的通知事件
+// 例如 OnNoticeType(types.NoticeTypeEssence) 匹配精华消息变更，处理函数可通过 ctx.EssenceEvent() 获取事件
+func (e *Engine) OnNoticeType(noticeType types.NoticeType, filters ...Filter) *Matcher {
+	matcher := newMatcher("notice:"+string(noticeType), func(ctx *Context) bool {
+		return ctx.GetNoticeType() == noticeType
+	}, filters...)
+	e.addMatcher(matcher)
+	return matcher
+}
+
 // OnRequest 请求事件
 func (e *Engine) OnRequest(filters ...Filter) *Matcher {
-	matcher := newMatcher(func(ctx *Context) bool {
+	matcher := newMatcher("request", func(ctx *Context) bool {
 		return ctx.Event.GetPostType() == "request"
 	}, filters...)
 	e.addMatcher(matcher)
 	return matcher
 }
 
+// OnBotStatus 机器人在线状态变化事件
+// 由框架根据心跳生成，处理函数可通过 ctx.Event.(*event.BotStatusEvent) 获取状态
+func (e *Engine) OnBotStatus(filters ...Filter) *Matcher {
+	matcher := newMatcher("bot_status", func(ctx *Context) bool {
+		_, ok := ctx.Event.(*event.BotStatusEvent)
+		return ok
+	}, filters...)
+	e.addMatcher(matcher)
+	return matcher
+}
+
+// OnRecalledMessage 群消息撤回事件（附带原消息）
+// 由框架根据消息历史生成，处理函数可通过 ctx.Event.(*event.RecalledMessageEvent) 获取原消息
+func (e *Engine) OnRecalledMessage(filters ...Filter) *Matcher {
+	matcher := newMatcher("recalled_message", func(ctx *Context) bool {
+		_, ok := ctx.Event.(*event.RecalledMessageEvent)
+		return ok
+	}, filters...)
+	e.addMatcher(matcher)
+	return matcher
+}
+
 // addMatcher 添加匹配器
+// 匹配器按注册顺序保存，分发时再按优先级排序，注册后调用 Priority 同样生效；
+// 匹配器列表写时复制，分发事件时读取的快照不受并发添加、移除影响
 func (e *Engine) addMatcher(matcher *Matcher) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.matchers = append(e.matchers, matcher)
+	matcher.engine = e
 
-	// 按优先级排序
-	sort.Slice(e.matchers, func(i, j int) bool {
-		return e.matchers[i].priority > e.matchers[j].priority
-	})
+	matchers := make([]*Matcher, len(e.matchers), len(e.matchers)+1)
+	copy(matchers, e.matchers)
+	e.matchers = append(matchers, matcher)
+	matchersVersion.Add(1)
 }
 
-// HandleEvent 处理事件
-func (e *Engine) HandleEvent(evt event.Event) {
-	// 创建上下文
-	ctx := NewContext(evt, e.bot)
+// Remove 移除匹配器，匹配器不属于该引擎时返回 false
+func (e *Engine) Remove(matcher *Matcher) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	// 使用中间件包装处理流程
-	handler := func(ctx *Context) {
-		e.handleEventInternal(ctx)
+	for i, m := range e.matchers {
+		if m != matcher {
+			continue
+		}
+		matchers := make([]*Matcher, 0, len(e.matchers)-1)
+		matchers = append(matchers, e.matchers[:i]...)
+		e.matchers = append(matchers, e.matchers[i+1:]...)
+		matcher.removed.Store(true)
+		matcher.stopExpireTimer()
+		matchersVersion.Add(1)
+		return true
 	}
+	return false
+}
 
-	// 应用引擎级中间件
-	e.mu.RLock()
-	mws := e.middlewares
-	e.mu.RUnlock()
-
-	for i := len(mws) - 1; i >= 0; i-- {
-		handler = mws[i](handler)
+// RemoveByID 按 ID 移除匹配器，不存在时返回 false
+func (e *Engine) RemoveByID(id int64) bool {
+	if m := e.MatcherByID(id); m != nil {
+		return e.Remove(m)
 	}
+	return false
+}
 
-	// 执行
-	handler(ctx)
+// MatcherByID 按 ID 获取匹配器，不存在时返回 nil
+func (e *Engine) MatcherByID(id int64) *Matcher {
+	for _, m := range e.snapshot() {
+		if m.id == id {
+			return m
+		}
+	}
+	return nil
 }
 
-// handleEventInternal 内部事件处理
-func (e *Engine) handleEventInternal(ctx *Context) {
+// snapshot 获取匹配器列表的快照，调用方不能修改返回的切片
+func (e *Engine) snapshot() []*Matcher {
 	e.mu.RLock()
-	matchers := make([]*Matcher, len(e.matchers))
-	copy(matchers, e.matchers)
-	e.mu.RUnlock()
+	defer e.mu.RUnlock()
+	return e.matchers
+}
 
-	// 遍历匹配器
-	for _, matcher := range matchers {
-		// 检查是否已中止
-		if ctx.IsAborted() {
-			break
-		}
+// HandleEvent 只将事件分发给当前引擎的匹配器
+// 机器人收到的事件由 BotManager 统一分发给所有引擎，一般不需要直接调用
+func (e *Engine) HandleEvent(evt event.Event) {
+	if !e.IsEnabled() {
+		return
+	}
+	dispatch(NewContext(evt, e.bot), []*Engine{e}, &e.matcherCache)
+}
 
-		if matcher.Match(ctx) {
-			// 使用 goroutine 处理事件，避免阻塞
-			go func(m *Matcher) {
-				defer func() {
-					if err := recover(); err != nil {
-						logger.Error("处理事件时发生错误", "error", err)
-					}
-				}()
-
-				m.Execute(ctx)
-			}(matcher)
-
-			// 标记已匹配
-			ctx.matched = true
-
-			// 检查是否应该阻止继续匹配
-			if matcher.block {
-				break
-			}
-		}
+// Storage 获取插件专用存储
+// 使用机器人的存储，键自动添加 "plugin:<引擎名称>:" 前缀；尚未关联机器人时返回 nil
+func (e *Engine) Storage() storage.Storage {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.bot == nil || e.bot.Storage == nil {
+		return nil
 	}
+	return storage.NewPrefixed(e.bot.Storage, "plugin:"+e.name+":")
 }
 
 // SetBot 设置 Bot
@@ -268,9 +389,22 @@ var (
 func RegisterEngine(engine *Engine) {
 	engineMu.Lock()
 	defer engineMu.Unlock()
+	if engine.GetName() == "" {
+		engine.Name(fmt.Sprintf("engine-%d", len(globalEngines)))
+	}
 	globalEngines = append(globalEngines, engine)
 }
 
+// GetEngine 根据名称获取引擎
+func GetEngine(name string) (*Engine, bool) {
+	for _, engine := range GetEngines() {
+		if engine.GetName() == name {
+			return engine, true
+		}
+	}
+	return nil, false
+}
+
 // GetEngines 获取所有引擎
 func GetEngines() []*Engine {
 	engineMu.Lock()
@@ -0,0 +1,203 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+// ErrorHandler 错误处理函数
+type ErrorHandler func(ctx *Context, err error)
+
+// DefaultErrorNotifyInterval 同一匹配器向超级用户报告错误的默认最小间隔
+const DefaultErrorNotifyInterval = time.Minute
+
+// UserError 面向用户的错误，处理函数返回时错误信息会回复给用户
+type UserError struct {
+	Message string // 回复给用户的信息
+	Err     error  // 原始错误，可为空
+}
+
+// NewUserError 创建面向用户的错误
+func NewUserError(message string) *UserError {
+	return &UserError{Message: message}
+}
+
+// UserErrorf 按格式创建面向用户的错误，格式中的 %w 会作为原始错误保留
+func UserErrorf(format string, args ...interface{}) *UserError {
+	err := fmt.Errorf(format, args...)
+	return &UserError{Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// Error 实现 error 接口
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+// Unwrap 返回原始错误
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError 处理函数 panic 时生成的错误
+type PanicError struct {
+	Value interface{} // panic 的值
+	Stack string      // 调用栈
+}
+
+// newPanicError 根据 recover 的值创建错误并记录调用栈
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{Value: value, Stack: string(debug.Stack())}
+}
+
+// Error 实现 error 接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// userMessage 获取应回复给用户的错误信息
+func userMessage(err error) (string, bool) {
+	var userErr *UserError
+	if errors.As(err, &userErr) {
+		return userErr.Message, true
+	}
+	var argsErr *ArgsError
+	if errors.As(err, &argsErr) {
+		return argsErr.Error(), true
+	}
+	return "", false
+}
+
+// ========== 错误处理钩子 ==========
+
+var (
+	globalErrorHandlers []ErrorHandler
+	errorHandlerMu      sync.RWMutex
+)
+
+// OnError 注册全局错误处理函数，所有引擎的处理函数返回错误或 panic 时调用
+func OnError(handler ErrorHandler) {
+	errorHandlerMu.Lock()
+	defer errorHandlerMu.Unlock()
+	globalErrorHandlers = append(globalErrorHandlers, handler)
+}
+
+// OnError 注册引擎的错误处理函数，先于全局错误处理函数调用
+func (e *Engine) OnError(handler ErrorHandler) *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errorHandlers = append(e.errorHandlers, handler)
+	return e
+}
+
+// handleError 处理处理函数返回的错误或 panic
+// 依次记录日志、回复面向用户的错误、调用引擎和全局错误处理函数、向超级用户报告
+func (m *Matcher) handleError(ctx *Context, err error) {
+	msg, isUserError := userMessage(err)
+
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		ctx.Logger.Error("处理事件时发生 panic", "matcher", m.name, "pattern", m.pattern, "error", panicErr.Value, "stack", panicErr.Stack)
+	case isUserError:
+		ctx.Logger.Debug("处理函数返回用户错误", "matcher", m.name, "pattern", m.pattern, "error", err)
+	default:
+		ctx.Logger.Error("处理函数返回错误", "matcher", m.name, "pattern", m.pattern, "error", err)
+	}
+
+	if isUserError && ctx.Bot != nil && ctx.Bot.API != nil {
+		ctx.Reply(msg)
+	}
+
+	var handlers []ErrorHandler
+	if m.engine != nil {
+		m.engine.mu.RLock()
+		handlers = append(handlers, m.engine.errorHandlers...)
+		m.engine.mu.RUnlock()
+	}
+	errorHandlerMu.RLock()
+	handlers = append(handlers, globalErrorHandlers...)
+	errorHandlerMu.RUnlock()
+
+	for _, handler := range handlers {
+		callErrorHandler(handler, ctx, err)
+	}
+
+	if !isUserError {
+		m.reportError(ctx, err)
+	}
+}
+
+// callErrorHandler 调用错误处理函数，错误处理函数本身的 panic 只记录日志
+func callErrorHandler(handler ErrorHandler, ctx *Context, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("错误处理函数发生 panic", "error", r)
+		}
+	}()
+	handler(ctx, err)
+}
+
+// allowErrorReport 判断匹配器是否可以报告错误，并发调用时同一报告间隔内只有一次返回 true
+func (bot *Bot) allowErrorReport(matcherID int64, now time.Time, interval time.Duration) bool {
+	last, loaded := bot.lastErrorReports.LoadOrStore(matcherID, now)
+	if !loaded {
+		return true
+	}
+	if now.Sub(last.(time.Time)) < interval {
+		return false
+	}
+	return bot.lastErrorReports.CompareAndSwap(matcherID, last, now)
+}
+
+// reportError 私聊向超级用户报告错误，同一匹配器在报告间隔内只报告一次
+func (m *Matcher) reportError(ctx *Context, err error) {
+	bot := ctx.Bot
+	if bot == nil || bot.API == nil || !bot.Config.NotifyErrors || len(bot.Config.SuperUsers) == 0 {
+		return
+	}
+
+	interval := bot.Config.ErrorNotifyInterval
+	if interval <= 0 {
+		interval = DefaultErrorNotifyInterval
+	}
+	if !bot.allowErrorReport(m.ID(), time.Now(), interval) {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "匹配器 %s（ID %d）处理事件时出错\n", m.name, m.ID())
+	if groupID := ctx.GetGroupID(); groupID != 0 {
+		fmt.Fprintf(&b, "群：%d\n", groupID)
+	}
+	if userID := ctx.GetUserID(); userID != 0 {
+		fmt.Fprintf(&b, "用户：%d\n", userID)
+	}
+	if text := ctx.GetPlainText(); text != "" {
+		fmt.Fprintf(&b, "消息：%s\n", text)
+	}
+	fmt.Fprintf(&b, "错误：%v", err)
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		stack := panicErr.Stack
+		if len(stack) > 1500 {
+			stack = stack[:1500] + "..."
+		}
+		b.WriteString("\n" + stack)
+	}
+
+	report := b.String()
+	go func() {
+		for _, su := range bot.Config.SuperUsers {
+			if _, err := bot.API.SendPrivateMsg(su, report); err != nil {
+				logger.Warn("向超级用户报告错误失败", "userID", su, "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,102 @@
+package xbot
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestErrorPipeline 测试用户错误回复、错误处理钩子和 panic 调用栈
+func TestErrorPipeline(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{Config: &Config{CommandPrefix: "/"}, API: api.NewClient(d)}
+	e := &Engine{name: "test"}
+	e.bot = bot
+
+	// 引擎钩子先于全局钩子调用
+	engineErrs := make(chan error, 4)
+	errs := make(chan error, 4)
+	e.OnError(func(ctx *Context, err error) { engineErrs <- err })
+	OnError(func(ctx *Context, err error) { errs <- err })
+	defer func() {
+		errorHandlerMu.Lock()
+		globalErrorHandlers = nil
+		errorHandlerMu.Unlock()
+	}()
+
+	e.OnCommand("fail").Handle(func(ctx *Context) error {
+		return UserErrorf("城市 %s 不存在", "火星")
+	})
+	e.OnCommand("panic").Handle(func(ctx *Context) {
+		panic("boom")
+	})
+
+	send := func(text string) error {
+		e.HandleEvent(&event.PrivateMessageEvent{
+			BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			UserID:        1,
+			ParsedMessage: message.Message{message.Text(text)},
+		})
+		select {
+		case err := <-errs:
+			if len(engineErrs) != 1 || <-engineErrs != err {
+				t.Errorf("Expected engine hook to be called before global hook for %s", text)
+			}
+			return err
+		case <-time.After(time.Second):
+			t.Fatalf("Expected error hooks to be called for %s", text)
+			return nil
+		}
+	}
+
+	err := send("/fail")
+	var userErr *UserError
+	if !errors.As(err, &userErr) || userErr.Message != "城市 火星 不存在" {
+		t.Errorf("Expected user error, got %v", err)
+	}
+	if call := d.lastCall(api.ActionSendPrivateMsg); call == nil || call["user_id"] != int64(1) {
+		t.Errorf("Expected user error to be replied, got %v", call)
+	}
+
+	err = send("/panic")
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || panicErr.Stack == "" {
+		t.Errorf("Expected panic error with stack, got %v", err)
+	}
+}
+
+// TestErrorReportThrottle 测试错误报告按匹配器 ID 节流，并发报告时只发送一次
+func TestErrorReportThrottle(t *testing.T) {
+	bot := &Bot{}
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if bot.allowErrorReport(1, now, time.Minute) {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 1 {
+		t.Errorf("Expected exactly one report, got %d", allowed.Load())
+	}
+
+	if !bot.allowErrorReport(2, now, time.Minute) {
+		t.Error("Expected matchers with different IDs to be throttled separately")
+	}
+	if !bot.allowErrorReport(1, now.Add(2*time.Minute), time.Minute) {
+		t.Error("Expected report to be allowed after the interval")
+	}
+}
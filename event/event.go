@@ -2,7 +2,9 @@ package event
 
 import (
 	"encoding/json"
-	"github.com/xiaoyi510/xbot/message"
+	"fmt"
+
+	"github.com/xiaoyi510/xbot/trace"
 	"github.com/xiaoyi510/xbot/types"
 )
 
@@ -18,6 +20,9 @@ type BaseEvent struct {
 	Time     int64          `json:"time"`      // 事件发生的时间戳
 	SelfID   int64          `json:"self_id"`   // 收到事件的机器人 QQ 号
 	PostType types.PostType `json:"post_type"` // 事件类型
+
+	span *trace.Span // 事件的追踪跨度
+	raw  []byte      // 原始 JSON
 }
 
 // GetTime 获取事件时间
@@ -35,142 +40,84 @@ func (e *BaseEvent) GetPostType() string {
 	return string(e.PostType)
 }
 
-// ParseEvent 解析事件
-func ParseEvent(data []byte) (Event, error) {
-	// 首先解析基础事件以确定类型
-	var base BaseEvent
-	if err := json.Unmarshal(data, &base); err != nil {
-		return nil, err
-	}
+// Span 获取事件的追踪跨度，未启用追踪时为 nil
+func (e *BaseEvent) Span() *trace.Span {
+	return e.span
+}
 
-	// 根据事件类型解析具体事件
-	switch base.PostType {
-	case types.PostTypeMessage:
-		return parseMessageEvent(data)
-	case types.PostTypeNotice:
-		return parseNoticeEvent(data)
-	case types.PostTypeRequest:
-		return parseRequestEvent(data)
-	case types.PostTypeMetaEvent:
-		return parseMetaEvent(data)
-	default:
-		// 未知事件类型，返回基础事件
-		return &base, nil
-	}
+// SetSpan 设置事件的追踪跨度
+func (e *BaseEvent) SetSpan(span *trace.Span) {
+	e.span = span
 }
 
-// parseMessageEvent 解析消息事件
-func parseMessageEvent(data []byte) (Event, error) {
-	var temp struct {
-		MessageType types.MessageType `json:"message_type"`
-	}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return nil, err
-	}
+// Raw 获取事件的原始 JSON，框架生成的事件返回 nil
+// 可用于读取实现扩展的、结构体中没有定义的字段
+func (e *BaseEvent) Raw() []byte {
+	return e.raw
+}
 
-	switch temp.MessageType {
-	case types.MessageTypePrivate:
-		var evt PrivateMessageEvent
-		if err := json.Unmarshal(data, &evt); err != nil {
-			return nil, err
-		}
-		evt.ParsedMessage = message.ParseMessage(evt.Message)
-		return &evt, nil
-	case types.MessageTypeGroup:
-		var evt GroupMessageEvent
-		if err := json.Unmarshal(data, &evt); err != nil {
-			return nil, err
-		}
-		evt.ParsedMessage = message.ParseMessage(evt.Message)
-		return &evt, nil
-	default:
-		return nil, json.Unmarshal(data, &BaseEvent{})
-	}
+// setRaw 设置事件的原始 JSON
+func (e *BaseEvent) setRaw(data []byte) {
+	e.raw = data
 }
 
-// parseNoticeEvent 解析通知事件
-func parseNoticeEvent(data []byte) (Event, error) {
-	var temp struct {
-		NoticeType types.NoticeType `json:"notice_type"`
-	}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return nil, err
+// RawOf 获取任意事件的原始 JSON
+func RawOf(evt Event) []byte {
+	if carrier, ok := evt.(interface{ Raw() []byte }); ok {
+		return carrier.Raw()
 	}
+	return nil
+}
 
-	switch temp.NoticeType {
-	case types.NoticeTypeGroupUpload:
-		var evt GroupUploadNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeGroupAdmin:
-		var evt GroupAdminNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeGroupDecrease:
-		var evt GroupDecreaseNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeGroupIncrease:
-		var evt GroupIncreaseNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeGroupBan:
-		var evt GroupBanNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeFriendAdd:
-		var evt FriendAddNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeGroupRecall:
-		var evt GroupRecallNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeFriendRecall:
-		var evt FriendRecallNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.NoticeTypeNotify:
-		var evt NotifyNoticeEvent
-		return &evt, json.Unmarshal(data, &evt)
-	default:
-		var evt BaseEvent
-		return &evt, json.Unmarshal(data, &evt)
+// SpanOf 获取任意事件的追踪跨度
+func SpanOf(evt Event) *trace.Span {
+	if carrier, ok := evt.(interface{ Span() *trace.Span }); ok {
+		return carrier.Span()
 	}
+	return nil
 }
 
-// parseRequestEvent 解析请求事件
-func parseRequestEvent(data []byte) (Event, error) {
-	var temp struct {
-		RequestType types.RequestType `json:"request_type"`
-	}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return nil, err
+// ParseEvent 解析事件
+// 启用追踪时会为事件创建根跨度，可通过 SpanOf 获取
+func ParseEvent(data []byte) (Event, error) {
+	span := trace.StartSpan(nil, "event")
+
+	evt, err := parseEvent(data)
+	if err != nil || evt == nil {
+		span.SetError(err)
+		span.End()
+		return evt, err
 	}
 
-	switch temp.RequestType {
-	case types.RequestTypeFriend:
-		var evt FriendRequestEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.RequestTypeGroup:
-		var evt GroupRequestEvent
-		return &evt, json.Unmarshal(data, &evt)
-	default:
-		var evt BaseEvent
-		return &evt, json.Unmarshal(data, &evt)
+	span.SetAttribute("post_type", evt.GetPostType()).
+		SetAttribute("self_id", evt.GetSelfID()).
+		SetAttribute("event_type", fmt.Sprintf("%T", evt))
+	if carrier, ok := evt.(interface{ SetSpan(*trace.Span) }); ok {
+		carrier.SetSpan(span)
 	}
+
+	return evt, nil
 }
 
-// parseMetaEvent 解析元事件
-func parseMetaEvent(data []byte) (Event, error) {
-	var temp struct {
-		MetaEventType types.MetaEventType `json:"meta_event_type"`
+// parseEvent 根据事件类型解析具体事件
+// 没有注册解码器的事件返回 BaseEvent，可通过 Raw 获取完整的原始 JSON
+func parseEvent(data []byte) (Event, error) {
+	// 首先解析基础事件以确定类型
+	var base BaseEvent
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
 	}
-	if err := json.Unmarshal(data, &temp); err != nil {
+
+	evt, err := decode(base.PostType, data)
+	if err != nil {
 		return nil, err
 	}
+	if evt == nil {
+		evt = &base
+	}
 
-	switch temp.MetaEventType {
-	case types.MetaEventTypeLifecycle:
-		var evt LifecycleMetaEvent
-		return &evt, json.Unmarshal(data, &evt)
-	case types.MetaEventTypeHeartbeat:
-		var evt HeartbeatMetaEvent
-		return &evt, json.Unmarshal(data, &evt)
-	default:
-		var evt BaseEvent
-		return &evt, json.Unmarshal(data, &evt)
+	if carrier, ok := evt.(interface{ setRaw([]byte) }); ok {
+		carrier.setRaw(data)
 	}
+	return evt, nil
 }
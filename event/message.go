@@ -128,3 +128,26 @@ func (e *GroupMessageEvent) IsAdmin() bool {
 func (e *GroupMessageEvent) IsMember() bool {
 	return e.Sender.Role == types.RoleMember
 }
+
+// MessageSentEvent 机器人自身发送的消息事件（go-cqhttp/NapCat 扩展）
+// 包括通过框架和其它客户端发送的消息，不会被 OnMessage 等消息匹配器匹配
+type MessageSentEvent struct {
+	BaseEvent
+	MessageType types.MessageType `json:"message_type"`        // private 或 group
+	SubType     string            `json:"sub_type"`            // 消息子类型
+	MessageID   int64             `json:"message_id"`          // 消息 ID
+	GroupID     int64             `json:"group_id,omitempty"`  // 群号，私聊为 0
+	UserID      int64             `json:"user_id"`             // 发送者 QQ 号（即机器人自身）
+	TargetID    int64             `json:"target_id,omitempty"` // 私聊接收者 QQ 号
+	Message     interface{}       `json:"message"`             // 消息内容
+	RawMessage  string            `json:"raw_message"`         // 原始消息内容
+	Sender      types.Sender      `json:"sender"`              // 发送者信息
+
+	// 解析后的消息
+	ParsedMessage message.Message `json:"-"`
+}
+
+// IsGroup 是否是群消息
+func (e *MessageSentEvent) IsGroup() bool {
+	return e.MessageType == types.MessageTypeGroup
+}
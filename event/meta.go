@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/xiaoyi510/xbot/types"
 )
 
@@ -43,3 +45,26 @@ func (e *HeartbeatMetaEvent) IsOnline() bool {
 func (e *HeartbeatMetaEvent) IsGood() bool {
 	return e.Status.Good
 }
+
+// BotStatusEvent 机器人在线状态变化事件
+// 由框架根据心跳生成，并非 OneBot 上报的事件
+type BotStatusEvent struct {
+	BaseEvent
+	MetaEventType types.MetaEventType `json:"meta_event_type"` // bot_status
+	Online        bool                `json:"online"`          // 是否恢复在线
+	Reason        string              `json:"reason"`          // 状态变化原因
+}
+
+// NewBotStatusEvent 创建机器人在线状态变化事件
+func NewBotStatusEvent(selfID int64, online bool, reason string) *BotStatusEvent {
+	return &BotStatusEvent{
+		BaseEvent: BaseEvent{
+			Time:     time.Now().Unix(),
+			SelfID:   selfID,
+			PostType: types.PostTypeMetaEvent,
+		},
+		MetaEventType: types.MetaEventTypeBotStatus,
+		Online:        online,
+		Reason:        reason,
+	}
+}
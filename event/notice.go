@@ -1,6 +1,7 @@
 package event
 
 import (
+	"github.com/xiaoyi510/xbot/message"
 	"github.com/xiaoyi510/xbot/types"
 )
 
@@ -123,6 +124,108 @@ type FriendRecallNoticeEvent struct {
 	MessageID  int64            `json:"message_id"`  // 被撤回的消息 ID
 }
 
+// GroupCardNoticeEvent 群成员名片变更事件
+// 名片变更通知并不保证实时，部分实现会延迟上报
+type GroupCardNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType `json:"notice_type"` // group_card
+	GroupID    int64            `json:"group_id"`    // 群号
+	UserID     int64            `json:"user_id"`     // 成员 QQ 号
+	CardNew    string           `json:"card_new"`    // 新名片
+	CardOld    string           `json:"card_old"`    // 旧名片
+}
+
+// OfflineFileNoticeEvent 接收到离线文件事件
+type OfflineFileNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType      `json:"notice_type"` // offline_file
+	UserID     int64                 `json:"user_id"`     // 发送者 QQ 号
+	File       types.OfflineFileInfo `json:"file"`        // 文件信息
+}
+
+// ClientStatusNoticeEvent 其他客户端在线状态变更事件（go-cqhttp 扩展）
+type ClientStatusNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType `json:"notice_type"` // client_status
+	Client     types.DeviceInfo `json:"client"`      // 客户端信息
+	Online     bool             `json:"online"`      // 当前是否在线
+}
+
+// EssenceNoticeEvent 精华消息变更事件（go-cqhttp 扩展）
+type EssenceNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType `json:"notice_type"` // essence
+	SubType    string           `json:"sub_type"`    // add 添加, delete 移除
+	GroupID    int64            `json:"group_id"`    // 群号
+	SenderID   int64            `json:"sender_id"`   // 消息发送者 QQ 号
+	OperatorID int64            `json:"operator_id"` // 操作者 QQ 号
+	MessageID  int64            `json:"message_id"`  // 消息 ID
+}
+
+// IsAdd 是否是设为精华
+func (e *EssenceNoticeEvent) IsAdd() bool {
+	return e.SubType == "add"
+}
+
+// IsDelete 是否是移出精华
+func (e *EssenceNoticeEvent) IsDelete() bool {
+	return e.SubType == "delete"
+}
+
+// EmojiLike 表情回应
+type EmojiLike struct {
+	EmojiID string `json:"emoji_id"` // 表情 ID
+	Count   int32  `json:"count"`    // 回应数量
+}
+
+// GroupMsgEmojiLikeNoticeEvent 群消息表情回应事件（NapCat/LLOneBot 扩展）
+type GroupMsgEmojiLikeNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType `json:"notice_type"` // group_msg_emoji_like
+	GroupID    int64            `json:"group_id"`    // 群号
+	UserID     int64            `json:"user_id"`     // 回应者 QQ 号
+	MessageID  int64            `json:"message_id"`  // 被回应的消息 ID
+	Likes      []EmojiLike      `json:"likes"`       // 表情回应列表
+	IsAdd      bool             `json:"is_add"`      // 添加或取消回应（NapCat）
+}
+
+// InputStatusNoticeEvent 对方正在输入事件（NapCat 扩展）
+// 以 notify 通知上报，sub_type 为 input_status
+type InputStatusNoticeEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType    `json:"notice_type"` // notify
+	SubType    types.NotifySubType `json:"sub_type"`    // input_status
+	GroupID    int64               `json:"group_id"`    // 群号，私聊为 0
+	UserID     int64               `json:"user_id"`     // 输入者 QQ 号
+	EventType  int32               `json:"event_type"`  // 1 正在输入, 2 结束输入
+	StatusText string              `json:"status_text"` // 状态文本，如“对方正在输入...”
+}
+
+// IsTyping 是否正在输入
+func (e *InputStatusNoticeEvent) IsTyping() bool {
+	return e.EventType == 1
+}
+
+// RecalledMessageEvent 群消息撤回事件（附带原消息）
+// 由框架在收到 group_recall 通知后根据消息历史生成，并非 OneBot 上报的事件；
+// 原消息不在消息历史中时不会生成
+type RecalledMessageEvent struct {
+	BaseEvent
+	NoticeType types.NoticeType `json:"notice_type"` // recalled_message
+	GroupID    int64            `json:"group_id"`    // 群号
+	UserID     int64            `json:"user_id"`     // 原消息发送者 QQ 号
+	OperatorID int64            `json:"operator_id"` // 撤回操作者 QQ 号
+	MessageID  int64            `json:"message_id"`  // 被撤回的消息 ID
+	Sender     types.Sender     `json:"sender"`      // 原消息发送者信息
+	SentTime   int64            `json:"sent_time"`   // 原消息发送时间戳
+	Message    message.Message  `json:"message"`     // 原消息内容
+}
+
+// IsSelfRecall 是否是发送者自己撤回
+func (e *RecalledMessageEvent) IsSelfRecall() bool {
+	return e.OperatorID == e.UserID
+}
+
 // NotifyNoticeEvent 群内提示事件
 type NotifyNoticeEvent struct {
 	BaseEvent
@@ -0,0 +1,52 @@
+package event
+
+import (
+	"testing"
+)
+
+// TestParseExtendedNotices 测试解析实现扩展的通知事件
+func TestParseExtendedNotices(t *testing.T) {
+	tests := []struct {
+		data  string
+		check func(Event) bool
+	}{
+		{`{"post_type":"notice","notice_type":"group_card","group_id":1,"user_id":2,"card_new":"新","card_old":"旧"}`, func(e Event) bool {
+			evt, ok := e.(*GroupCardNoticeEvent)
+			return ok && evt.CardNew == "新" && evt.CardOld == "旧"
+		}},
+		{`{"post_type":"notice","notice_type":"offline_file","user_id":2,"file":{"name":"a.txt","size":10,"url":"http://x"}}`, func(e Event) bool {
+			evt, ok := e.(*OfflineFileNoticeEvent)
+			return ok && evt.File.Name == "a.txt" && evt.File.Size == 10
+		}},
+		{`{"post_type":"notice","notice_type":"essence","sub_type":"add","group_id":1,"sender_id":2,"operator_id":3,"message_id":4}`, func(e Event) bool {
+			evt, ok := e.(*EssenceNoticeEvent)
+			return ok && evt.IsAdd() && evt.SenderID == 2 && evt.MessageID == 4
+		}},
+		{`{"post_type":"notice","notice_type":"group_msg_emoji_like","group_id":1,"user_id":2,"message_id":3,"likes":[{"emoji_id":"76","count":1}]}`, func(e Event) bool {
+			evt, ok := e.(*GroupMsgEmojiLikeNoticeEvent)
+			return ok && len(evt.Likes) == 1 && evt.Likes[0].EmojiID == "76"
+		}},
+		{`{"post_type":"notice","notice_type":"notify","sub_type":"input_status","user_id":2,"event_type":1,"status_text":"对方正在输入..."}`, func(e Event) bool {
+			evt, ok := e.(*InputStatusNoticeEvent)
+			return ok && evt.IsTyping()
+		}},
+		{`{"post_type":"notice","notice_type":"notify","sub_type":"poke","group_id":1,"user_id":2,"target_id":3}`, func(e Event) bool {
+			evt, ok := e.(*NotifyNoticeEvent)
+			return ok && evt.IsPoke()
+		}},
+		{`{"post_type":"notice","notice_type":"client_status","online":true,"client":{"app_id":1,"device_name":"手机","device_kind":"phone"}}`, func(e Event) bool {
+			evt, ok := e.(*ClientStatusNoticeEvent)
+			return ok && evt.Online && evt.Client.DeviceName == "手机"
+		}},
+	}
+
+	for _, tt := range tests {
+		evt, err := ParseEvent([]byte(tt.data))
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %v", tt.data, err)
+		}
+		if !tt.check(evt) {
+			t.Errorf("Unexpected event %T %+v for %s", evt, evt, tt.data)
+		}
+	}
+}
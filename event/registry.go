@@ -0,0 +1,195 @@
+package event
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// Decoder 事件解码器，将原始 JSON 解码为具体事件
+type Decoder func(data []byte) (Event, error)
+
+// registryKey 解码器注册键
+type registryKey struct {
+	postType types.PostType
+	subType  string
+}
+
+var (
+	registryMu sync.RWMutex
+	decoders   = make(map[registryKey]Decoder)
+
+	// subTypeFields 各事件类型用于区分具体事件的字段
+	subTypeFields = map[types.PostType]string{
+		types.PostTypeMessage:     "message_type",
+		types.PostTypeMessageSent: "message_type",
+		types.PostTypeNotice:      "notice_type",
+		types.PostTypeRequest:     "request_type",
+		types.PostTypeMetaEvent:   "meta_event_type",
+	}
+)
+
+// Register 注册事件解码器
+// subType 为该事件类型区分字段的值（如 notice 事件的 notice_type），为空时作为该事件类型的默认解码器；
+// 重复注册会覆盖已有的解码器，可用于替换内置事件的解析
+//
+// 示例：
+//
+//	event.Register(types.PostTypeNotice, "group_title", event.JSONDecoder[GroupTitleEvent]())
+func Register(postType types.PostType, subType string, decoder Decoder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	decoders[registryKey{postType, subType}] = decoder
+}
+
+// RegisterPostType 注册新的事件类型及其区分字段
+// 例如实现扩展的 post_type 为 custom、以 custom_type 字段区分具体事件时，
+// 调用 RegisterPostType("custom", "custom_type") 后即可按 custom_type 的值注册解码器
+func RegisterPostType(postType types.PostType, field string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	subTypeFields[postType] = field
+}
+
+// JSONDecoder 创建将 JSON 直接解码为 T 的解码器
+func JSONDecoder[T any, P interface {
+	*T
+	Event
+}]() Decoder {
+	return func(data []byte) (Event, error) {
+		var evt P = new(T)
+		if err := json.Unmarshal(data, evt); err != nil {
+			return nil, err
+		}
+		return evt, nil
+	}
+}
+
+// decode 按注册的解码器解析事件，没有匹配的解码器时返回 nil
+func decode(postType types.PostType, data []byte) (Event, error) {
+	registryMu.RLock()
+	field, hasField := subTypeFields[postType]
+	registryMu.RUnlock()
+
+	subType := ""
+	if hasField {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		// 区分字段不是字符串时按默认解码器处理
+		_ = json.Unmarshal(fields[field], &subType)
+	}
+
+	registryMu.RLock()
+	decoder, ok := decoders[registryKey{postType, subType}]
+	if !ok {
+		decoder, ok = decoders[registryKey{postType, ""}]
+	}
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, nil
+	}
+	return decoder(data)
+}
+
+// ========== 内置事件 ==========
+
+func init() {
+	// 消息事件
+	Register(types.PostTypeMessage, string(types.MessageTypePrivate), decodePrivateMessage)
+	Register(types.PostTypeMessage, string(types.MessageTypeGroup), decodeGroupMessage)
+	Register(types.PostTypeMessageSent, "", decodeMessageSent)
+
+	// 通知事件
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupUpload), JSONDecoder[GroupUploadNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupAdmin), JSONDecoder[GroupAdminNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupDecrease), JSONDecoder[GroupDecreaseNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupIncrease), JSONDecoder[GroupIncreaseNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupBan), JSONDecoder[GroupBanNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeFriendAdd), JSONDecoder[FriendAddNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupRecall), JSONDecoder[GroupRecallNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeFriendRecall), JSONDecoder[FriendRecallNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeNotify), decodeNotify)
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupCard), JSONDecoder[GroupCardNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeOfflineFile), JSONDecoder[OfflineFileNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeClientStatus), JSONDecoder[ClientStatusNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeEssence), JSONDecoder[EssenceNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeGroupMsgEmojiLike), JSONDecoder[GroupMsgEmojiLikeNoticeEvent]())
+	Register(types.PostTypeNotice, string(types.NoticeTypeBotOffline), decodeBotOffline)
+
+	// 请求事件
+	Register(types.PostTypeRequest, string(types.RequestTypeFriend), JSONDecoder[FriendRequestEvent]())
+	Register(types.PostTypeRequest, string(types.RequestTypeGroup), JSONDecoder[GroupRequestEvent]())
+
+	// 元事件
+	Register(types.PostTypeMetaEvent, string(types.MetaEventTypeLifecycle), JSONDecoder[LifecycleMetaEvent]())
+	Register(types.PostTypeMetaEvent, string(types.MetaEventTypeHeartbeat), JSONDecoder[HeartbeatMetaEvent]())
+}
+
+// decodePrivateMessage 解析私聊消息事件
+func decodePrivateMessage(data []byte) (Event, error) {
+	var evt PrivateMessageEvent
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return nil, err
+	}
+	evt.ParsedMessage = message.ParseMessage(evt.Message)
+	return &evt, nil
+}
+
+// decodeGroupMessage 解析群消息事件
+func decodeGroupMessage(data []byte) (Event, error) {
+	var evt GroupMessageEvent
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return nil, err
+	}
+	evt.ParsedMessage = message.ParseMessage(evt.Message)
+	return &evt, nil
+}
+
+// decodeMessageSent 解析机器人自身发送的消息事件
+func decodeMessageSent(data []byte) (Event, error) {
+	var evt MessageSentEvent
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return nil, err
+	}
+	evt.ParsedMessage = message.ParseMessage(evt.Message)
+	return &evt, nil
+}
+
+// decodeNotify 解析群内提示事件，input_status 解析为 InputStatusNoticeEvent
+func decodeNotify(data []byte) (Event, error) {
+	var temp struct {
+		SubType types.NotifySubType `json:"sub_type"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return nil, err
+	}
+	if temp.SubType == types.NotifySubTypeInputStatus {
+		return JSONDecoder[InputStatusNoticeEvent]()(data)
+	}
+	return JSONDecoder[NotifyNoticeEvent]()(data)
+}
+
+// decodeBotOffline 将 NapCat 上报的 bot_offline 通知解析为 BotStatusEvent
+func decodeBotOffline(data []byte) (Event, error) {
+	var temp struct {
+		BaseEvent
+		Tag     string `json:"tag"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return nil, err
+	}
+
+	reason := temp.Message
+	if temp.Tag != "" {
+		reason = temp.Tag + "：" + temp.Message
+	}
+	evt := NewBotStatusEvent(temp.SelfID, false, reason)
+	evt.Time = temp.Time
+	return evt, nil
+}
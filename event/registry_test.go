@@ -0,0 +1,64 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// botOfflineEvent 测试用的自定义事件
+type botOfflineEvent struct {
+	BaseEvent
+	NoticeType string `json:"notice_type"`
+	Message    string `json:"message"`
+}
+
+// TestRegisterDecoder 测试注册自定义解码器
+func TestRegisterDecoder(t *testing.T) {
+	Register(types.PostTypeNotice, "test_bot_offline", JSONDecoder[botOfflineEvent]())
+
+	evt, err := ParseEvent([]byte(`{"post_type":"notice","notice_type":"test_bot_offline","self_id":1,"message":"掉线"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	e, ok := evt.(*botOfflineEvent)
+	if !ok || e.Message != "掉线" || e.SelfID != 1 {
+		t.Fatalf("Unexpected event %T %+v", evt, evt)
+	}
+	if len(e.Raw()) == 0 {
+		t.Error("Expected raw JSON to be retained")
+	}
+}
+
+// TestUnknownEventKeepsRaw 测试未注册的事件返回 BaseEvent 并保留原始 JSON
+func TestUnknownEventKeepsRaw(t *testing.T) {
+	for _, data := range []string{
+		`{"post_type":"message","message_type":"guild","self_id":1}`,
+		`{"post_type":"notice","notice_type":"unknown","self_id":1}`,
+		`{"post_type":"custom","self_id":1}`,
+	} {
+		evt, err := ParseEvent([]byte(data))
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", data, err)
+		}
+		if _, ok := evt.(*BaseEvent); !ok {
+			t.Errorf("Expected *BaseEvent for %s, got %T", data, evt)
+			continue
+		}
+		if string(RawOf(evt)) != data {
+			t.Errorf("Expected raw JSON %s, got %s", data, RawOf(evt))
+		}
+	}
+}
+
+// TestParseMessageSent 测试解析机器人自身发送的消息
+func TestParseMessageSent(t *testing.T) {
+	evt, err := ParseEvent([]byte(`{"post_type":"message_sent","message_type":"private","self_id":1,"user_id":1,"target_id":2,"message_id":3,"message":[{"type":"text","data":{"text":"hi"}}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	e, ok := evt.(*MessageSentEvent)
+	if !ok || e.TargetID != 2 || e.ParsedMessage.GetPlainText() != "hi" {
+		t.Fatalf("Unexpected event %T %+v", evt, evt)
+	}
+}
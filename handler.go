@@ -0,0 +1,348 @@
+package xbot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/storage"
+)
+
+// 处理函数的参数按类型注入：
+//
+//	*Context            当前上下文
+//	event.Event         当前事件
+//	*event.XxxEvent     具体事件，事件类型不符时匹配器不匹配（见 handlerEventGuard）
+//	*RegexMatch         正则匹配结果（OnRegex）
+//	*api.Client         当前机器人的 API 客户端
+//	message.Message     消息内容（*message.Message 同理），非消息事件为 nil
+//	storage.Storage     插件专用存储，键自动添加 "plugin:<引擎名称>:" 前缀
+//	结构体或结构体指针  从命令参数解析，见 parseArgs
+//
+// 处理函数可以返回 error，返回的错误由错误处理流程统一处理（见 errors.go），*ArgsError 会回复给用户：
+//
+//	engine.OnCommand("ban").Handle(func(ctx *xbot.Context, e *event.GroupMessageEvent, args struct {
+//	    UserID   int64 `arg:"required"`
+//	    Duration int
+//	}) error {
+//	    return ctx.SetGroupBan(e.GroupID, args.UserID, int64(args.Duration))
+//	})
+
+// handlerInvoker 编译后的处理函数
+type handlerInvoker func(ctx *Context, m *Matcher) error
+
+// paramResolver 解析处理函数的参数，skip 为 true 时不调用处理函数
+type paramResolver func(ctx *Context, m *Matcher) (v reflect.Value, skip bool, err error)
+
+var (
+	contextType    = reflect.TypeOf((*Context)(nil))
+	eventType      = reflect.TypeOf((*event.Event)(nil)).Elem()
+	regexMatchType = reflect.TypeOf((*RegexMatch)(nil))
+	apiClientType  = reflect.TypeOf((*api.Client)(nil))
+	messageType    = reflect.TypeOf(message.Message(nil))
+	messagePtrType = reflect.TypeOf((*message.Message)(nil))
+	storageType    = reflect.TypeOf((*storage.Storage)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// compileHandler 检查处理函数签名并生成调用函数
+func compileHandler(handler interface{}) (handlerInvoker, error) {
+	switch fn := handler.(type) {
+	case nil:
+		return nil, errors.New("处理函数为空")
+	case func(*Context):
+		return func(ctx *Context, _ *Matcher) error {
+			fn(ctx)
+			return nil
+		}, nil
+	case func(*Context) error:
+		return func(ctx *Context, _ *Matcher) error {
+			return fn(ctx)
+		}, nil
+	}
+
+	fnValue := reflect.ValueOf(handler)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("处理函数必须是函数，实际为 %s", fnType)
+	}
+	if fnType.IsVariadic() {
+		return nil, fmt.Errorf("处理函数不支持可变参数：%s", fnType)
+	}
+	if fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0) != errorType) {
+		return nil, fmt.Errorf("处理函数只能没有返回值或返回 error：%s", fnType)
+	}
+
+	resolvers := make([]paramResolver, fnType.NumIn())
+	for i := range resolvers {
+		resolver, err := resolverFor(fnType.In(i))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个参数：%w", i+1, err)
+		}
+		resolvers[i] = resolver
+	}
+
+	return func(ctx *Context, m *Matcher) error {
+		args := make([]reflect.Value, len(resolvers))
+		for i, resolve := range resolvers {
+			v, skip, err := resolve(ctx, m)
+			if err != nil {
+				return err
+			}
+			if skip {
+				return nil
+			}
+			args[i] = v
+		}
+
+		out := fnValue.Call(args)
+		if len(out) == 1 && !out[0].IsNil() {
+			return out[0].Interface().(error)
+		}
+		return nil
+	}, nil
+}
+
+// handlerEventGuard 根据处理函数中的具体事件参数生成事件类型检查
+// 匹配器在 Match 中调用该检查，事件类型不符时视为不匹配，不计入命中次数也不会阻止后续匹配器；
+// 处理函数没有具体事件参数时返回 nil
+func handlerEventGuard(handler interface{}) func(ctx *Context) bool {
+	fnType := reflect.TypeOf(handler)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return nil
+	}
+
+	var types []reflect.Type
+	for i := 0; i < fnType.NumIn(); i++ {
+		if t := fnType.In(i); t != eventType && t.Implements(eventType) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	return func(ctx *Context) bool {
+		if ctx.Event == nil {
+			return false
+		}
+		evtType := reflect.TypeOf(ctx.Event)
+		for _, t := range types {
+			if !evtType.AssignableTo(t) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// resolverFor 根据参数类型选择注入方式
+func resolverFor(t reflect.Type) (paramResolver, error) {
+	switch t {
+	case contextType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			return reflect.ValueOf(ctx), false, nil
+		}, nil
+	case eventType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			if ctx.Event == nil {
+				return reflect.Zero(t), false, nil
+			}
+			return reflect.ValueOf(ctx.Event), false, nil
+		}, nil
+	case regexMatchType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			return reflect.ValueOf(ctx.RegexResult), false, nil
+		}, nil
+	case apiClientType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			if ctx.Bot == nil || ctx.Bot.API == nil {
+				return reflect.Zero(t), false, nil
+			}
+			return reflect.ValueOf(ctx.API()), false, nil
+		}, nil
+	case messageType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			if msg := ctx.GetMessage(); msg != nil {
+				return reflect.ValueOf(*msg), false, nil
+			}
+			return reflect.Zero(t), false, nil
+		}, nil
+	case messagePtrType:
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			return reflect.ValueOf(ctx.GetMessage()), false, nil
+		}, nil
+	case storageType:
+		return func(ctx *Context, m *Matcher) (reflect.Value, bool, error) {
+			if m.engine != nil {
+				if s := m.engine.Storage(); s != nil {
+					return reflect.ValueOf(s), false, nil
+				}
+			}
+			return reflect.Zero(t), false, nil
+		}, nil
+	}
+
+	// 具体事件类型，事件类型不符时跳过处理函数
+	if t.Implements(eventType) {
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			if ctx.Event == nil || !reflect.TypeOf(ctx.Event).AssignableTo(t) {
+				return reflect.Value{}, true, nil
+			}
+			return reflect.ValueOf(ctx.Event), false, nil
+		}, nil
+	}
+
+	// 命令参数结构体
+	structType := t
+	if t.Kind() == reflect.Ptr {
+		structType = t.Elem()
+	}
+	if structType.Kind() == reflect.Struct {
+		if err := checkArgsStruct(structType); err != nil {
+			return nil, err
+		}
+		return func(ctx *Context, _ *Matcher) (reflect.Value, bool, error) {
+			v, err := parseArgs(structType, ctx.GetArgs())
+			if err != nil {
+				return reflect.Value{}, false, err
+			}
+			if t.Kind() == reflect.Ptr {
+				return v, false, nil
+			}
+			return v.Elem(), false, nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("不支持的参数类型 %s", t)
+}
+
+// ========== 命令参数 ==========
+
+// ArgsError 命令参数错误，错误信息会回复给用户
+type ArgsError struct {
+	Field  string // 参数名称
+	Value  string // 用户输入的值，缺少参数时为空
+	Reason string // 错误原因
+}
+
+// Error 实现 error 接口
+func (e *ArgsError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("参数 %s %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("参数 %s 的值 %q %s", e.Field, e.Value, e.Reason)
+}
+
+// argField 参数结构体中的字段
+type argField struct {
+	index    int
+	name     string
+	required bool
+}
+
+// argFields 获取参数结构体中按顺序解析的字段
+// 未导出字段和标签为 `arg:"-"` 的字段不解析，标签为 `arg:"required"` 的字段缺少时报错
+func argFields(t reflect.Type) []argField {
+	var fields []argField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("arg")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		fields = append(fields, argField{index: i, name: f.Name, required: tag == "required"})
+	}
+	return fields
+}
+
+// checkArgsStruct 检查参数结构体的字段类型是否支持
+func checkArgsStruct(t reflect.Type) error {
+	fields := argFields(t)
+	for i, f := range fields {
+		ft := t.Field(f.index).Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String {
+			if i != len(fields)-1 {
+				return fmt.Errorf("参数结构体 %s 中只有最后一个字段可以是 []string", t)
+			}
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			return fmt.Errorf("参数结构体 %s 的字段 %s 类型 %s 不支持", t, f.name, ft)
+		}
+	}
+	return nil
+}
+
+// parseArgs 将命令参数按空白分隔后依次填入结构体字段，返回结构体指针
+// 最后一个字段为 []string 时接收剩余的所有参数
+func parseArgs(t reflect.Type, args string) (reflect.Value, error) {
+	v := reflect.New(t)
+	words := strings.Fields(args)
+
+	for i, f := range argFields(t) {
+		field := v.Elem().Field(f.index)
+		if field.Kind() == reflect.Slice {
+			if i < len(words) {
+				field.Set(reflect.ValueOf(words[i:]))
+			} else if f.required {
+				return v, &ArgsError{Field: f.name, Reason: "不能为空"}
+			}
+			break
+		}
+
+		if i >= len(words) {
+			if f.required {
+				return v, &ArgsError{Field: f.name, Reason: "不能为空"}
+			}
+			continue
+		}
+		if err := setArg(field, words[i]); err != nil {
+			return v, &ArgsError{Field: f.name, Value: words[i], Reason: err.Error()}
+		}
+	}
+	return v, nil
+}
+
+// setArg 将字符串转换为字段类型并赋值
+func setArg(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("不是有效的布尔值")
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的整数")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的非负整数")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return errors.New("不是有效的数字")
+		}
+		field.SetFloat(n)
+	}
+	return nil
+}
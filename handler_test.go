@@ -0,0 +1,126 @@
+package xbot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestCompileHandlerSignature 测试注册时检查处理函数签名
+func TestCompileHandlerSignature(t *testing.T) {
+	valid := []interface{}{
+		func(*Context) {},
+		func(*Context) error { return nil },
+		func(*event.GroupMessageEvent, message.Message, storage.Storage) {},
+		func(*Context, struct{ Name string }) error { return nil },
+	}
+	for _, h := range valid {
+		if _, err := compileHandler(h); err != nil {
+			t.Errorf("Expected %T to be valid, got %v", h, err)
+		}
+	}
+
+	invalid := []interface{}{
+		nil,
+		"not a function",
+		func(*Context) int { return 0 },
+		func(*Context, chan int) {},
+		func(struct {
+			Tags []string
+			Name string
+		}) {},
+		func(...*Context) {},
+	}
+	for _, h := range invalid {
+		if _, err := compileHandler(h); err == nil {
+			t.Errorf("Expected %T to be invalid", h)
+		}
+	}
+}
+
+// TestHandlerInjection 测试按类型注入参数和命令参数解析
+func TestHandlerInjection(t *testing.T) {
+	e := &Engine{name: "test"}
+	bot := &Bot{Config: &Config{CommandPrefix: "/"}, Storage: storage.NewMemoryStorage()}
+	e.bot = bot
+	m := e.OnCommand("ban")
+
+	type banArgs struct {
+		UserID   int64 `arg:"required"`
+		Duration int
+		Reason   []string
+	}
+
+	var gotArgs *banArgs
+	var gotMsg message.Message
+	m.Handle(func(evt *event.GroupMessageEvent, args *banArgs, msg message.Message, s storage.Storage) error {
+		gotArgs, gotMsg = args, msg
+		return s.Set("last", []byte("ok"))
+	})
+
+	newCtx := func(evt event.Event) *Context {
+		return NewContext(evt, bot)
+	}
+	base := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage}
+
+	msg := message.Message{message.Text("/ban 123 60 刷屏 广告")}
+	m.Execute(newCtx(&event.GroupMessageEvent{BaseEvent: base, GroupID: 1, ParsedMessage: msg}))
+	if gotArgs == nil || gotArgs.UserID != 123 || gotArgs.Duration != 60 || len(gotArgs.Reason) != 2 {
+		t.Fatalf("Unexpected args: %+v", gotArgs)
+	}
+	if len(gotMsg) != 1 {
+		t.Errorf("Expected message to be injected, got %v", gotMsg)
+	}
+	if !bot.Storage.Has("plugin:test:last") {
+		t.Error("Expected plugin storage key to be prefixed")
+	}
+
+	// 事件类型不符时匹配器不匹配，不调用处理函数
+	gotArgs = nil
+	private := newCtx(&event.PrivateMessageEvent{BaseEvent: base, ParsedMessage: msg})
+	if m.Match(private) {
+		t.Error("Expected matcher not to match private message")
+	}
+	m.Execute(private)
+	if gotArgs != nil {
+		t.Error("Expected handler to be skipped for private message")
+	}
+
+	// 参数错误
+	_, err := parseArgs(reflect.TypeOf(banArgs{}), "abc")
+	var argsErr *ArgsError
+	if !errors.As(err, &argsErr) || argsErr.Field != "UserID" {
+		t.Errorf("Expected ArgsError for UserID, got %v", err)
+	}
+	if _, err := parseArgs(reflect.TypeOf(banArgs{}), ""); err == nil {
+		t.Error("Expected error for missing required arg")
+	}
+}
+
+// TestEventTypeGuard 测试事件类型不符的匹配器不消耗次数，也不阻止后续匹配器
+func TestEventTypeGuard(t *testing.T) {
+	e := &Engine{name: "test"}
+	e.bot = &Bot{Config: &Config{CommandPrefix: "/", DispatchMode: DispatchSequential}}
+
+	groupOnly := e.OnCommand("ping").Priority(10).SetBlock().Times(1).
+		Handle(func(ctx *Context, evt *event.GroupMessageEvent) {})
+	called := false
+	e.OnCommand("ping").Handle(func(ctx *Context) { called = true })
+
+	e.HandleEvent(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		UserID:        1,
+		ParsedMessage: message.Message{message.Text("/ping")},
+	})
+	if !called {
+		t.Error("Expected lower priority matcher to run when event type does not match")
+	}
+	if !groupOnly.IsEnabled() || groupOnly.hits.Load() != 0 {
+		t.Error("Expected unmatched event type not to consume Times")
+	}
+}
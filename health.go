@@ -0,0 +1,331 @@
+package xbot
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/metrics"
+)
+
+// defaultHeartbeatInterval 心跳事件未携带间隔时使用的默认间隔
+const defaultHeartbeatInterval = 30 * time.Second
+
+// BotHealth 机器人健康状态
+type BotHealth struct {
+	SelfID        int64         `json:"self_id"`
+	LastHeartbeat time.Time     `json:"last_heartbeat"`
+	Interval      time.Duration `json:"interval"` // OneBot 上报的心跳间隔
+	Online        bool          `json:"online"`   // 最近一次心跳的 status.online
+	Good          bool          `json:"good"`     // 最近一次心跳的 status.good
+	Healthy       bool          `json:"healthy"`
+	Reason        string        `json:"reason,omitempty"` // 不健康的原因
+}
+
+// healthMonitor 根据心跳元事件跟踪每个机器人的健康状态
+type healthMonitor struct {
+	mu       sync.RWMutex
+	bots     map[int64]*BotHealth
+	timeout  time.Duration // 心跳超时时间，为 0 时使用 3 倍心跳间隔
+	onChange func(selfID int64, online bool, reason string)
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// newHealthMonitor 创建健康监控
+func newHealthMonitor(timeout time.Duration, onChange func(selfID int64, online bool, reason string)) *healthMonitor {
+	return &healthMonitor{
+		bots:     make(map[int64]*BotHealth),
+		timeout:  timeout,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+	}
+}
+
+// start 启动心跳超时检查
+func (h *healthMonitor) start() {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				h.check(time.Now())
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close 停止心跳超时检查
+func (h *healthMonitor) close() {
+	h.once.Do(func() { close(h.stop) })
+}
+
+// recordHeartbeat 记录心跳
+func (h *healthMonitor) recordHeartbeat(evt *event.HeartbeatMetaEvent, now time.Time) {
+	interval := time.Duration(evt.Interval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+
+	healthy, reason := true, ""
+	if !evt.IsOnline() {
+		healthy, reason = false, "OneBot 报告 QQ 已离线"
+	}
+
+	h.mu.Lock()
+	bot, ok := h.bots[evt.SelfID]
+	if !ok {
+		bot = &BotHealth{SelfID: evt.SelfID}
+		h.bots[evt.SelfID] = bot
+	}
+	bot.LastHeartbeat = now
+	bot.Interval = interval
+	bot.Online = evt.IsOnline()
+	bot.Good = evt.IsGood()
+	changed := !ok && !healthy || ok && bot.Healthy != healthy
+	bot.Healthy = healthy
+	bot.Reason = reason
+	h.mu.Unlock()
+
+	if changed {
+		if healthy {
+			reason = "心跳恢复"
+		}
+		h.notify(evt.SelfID, healthy, reason)
+	}
+}
+
+// recordOffline 记录 OneBot 实现上报的离线通知（如 NapCat 的 bot_offline），立即标记为不健康，
+// 不等待心跳超时；返回状态是否发生变化
+// 通知本身会作为 BotStatusEvent 分发，因此这里不再调用 onChange，避免重复分发
+func (h *healthMonitor) recordOffline(selfID int64, reason string) bool {
+	if reason == "" {
+		reason = "OneBot 报告机器人离线"
+	}
+
+	h.mu.Lock()
+	bot, ok := h.bots[selfID]
+	if !ok {
+		bot = &BotHealth{SelfID: selfID, Interval: defaultHeartbeatInterval}
+		h.bots[selfID] = bot
+	}
+	changed := !ok || bot.Healthy
+	bot.Online = false
+	bot.Healthy = false
+	bot.Reason = reason
+	h.mu.Unlock()
+
+	if changed {
+		logger.Warn("机器人离线", "selfID", selfID, "reason", reason)
+	}
+	return changed
+}
+
+// check 检查心跳是否超时
+func (h *healthMonitor) check(now time.Time) {
+	type change struct {
+		selfID int64
+		reason string
+	}
+	var changes []change
+
+	h.mu.Lock()
+	for _, bot := range h.bots {
+		if !bot.Healthy {
+			continue
+		}
+		timeout := h.timeout
+		if timeout <= 0 {
+			timeout = 3 * bot.Interval
+		}
+		if elapsed := now.Sub(bot.LastHeartbeat); elapsed > timeout {
+			bot.Healthy = false
+			bot.Reason = fmt.Sprintf("心跳超时（%s 未收到心跳）", elapsed.Truncate(time.Second))
+			changes = append(changes, change{bot.SelfID, bot.Reason})
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range changes {
+		h.notify(c.selfID, false, c.reason)
+	}
+}
+
+// notify 通知状态变化
+func (h *healthMonitor) notify(selfID int64, online bool, reason string) {
+	if online {
+		logger.Info("机器人恢复在线", "selfID", selfID, "reason", reason)
+	} else {
+		logger.Warn("机器人离线", "selfID", selfID, "reason", reason)
+	}
+	if h.onChange != nil {
+		h.onChange(selfID, online, reason)
+	}
+}
+
+// snapshot 获取所有机器人的健康状态，按 SelfID 排序
+func (h *healthMonitor) snapshot() []BotHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]BotHealth, 0, len(h.bots))
+	for _, bot := range h.bots {
+		result = append(result, *bot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SelfID < result[j].SelfID
+	})
+	return result
+}
+
+// get 获取指定机器人的健康状态
+func (h *healthMonitor) get(selfID int64) (BotHealth, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if bot, ok := h.bots[selfID]; ok {
+		return *bot, true
+	}
+	return BotHealth{}, false
+}
+
+// ========== BotManager 接口 ==========
+
+// BotHealth 获取指定机器人的健康状态
+// 尚未收到过心跳的机器人返回 false
+func (bm *BotManager) BotHealth(selfID int64) (BotHealth, bool) {
+	return bm.health.get(selfID)
+}
+
+// AllBotHealth 获取所有已收到心跳的机器人的健康状态
+func (bm *BotManager) AllBotHealth() []BotHealth {
+	return bm.health.snapshot()
+}
+
+// IsHealthy 是否健康：所有已收到心跳的机器人都处于在线状态
+func (bm *BotManager) IsHealthy() bool {
+	for _, h := range bm.health.snapshot() {
+		if !h.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReady 是否就绪：至少有一个驱动器已连接，且至少有一个机器人可以处理事件
+// 对于不上报心跳的驱动器（如 HTTP），只要已收到该机器人的事件即视为可用
+func (bm *BotManager) IsReady() bool {
+	connected := false
+	for _, d := range bm.drivers {
+		if d.IsConnected() {
+			connected = true
+			break
+		}
+	}
+	if !connected {
+		return false
+	}
+
+	for _, bot := range bm.GetAllBots() {
+		if h, ok := bm.health.get(bot.SelfID); !ok || h.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// onBotStatusChange 机器人在线状态变化时分发事件并通知超级用户
+func (bm *BotManager) onBotStatusChange(selfID int64, online bool, reason string) {
+	bm.handleEvent(event.NewBotStatusEvent(selfID, online, reason))
+
+	if bm.config.NotifyBotStatus {
+		go bm.notifySuperUsers(selfID, online, reason)
+	}
+}
+
+// notifySuperUsers 通过私聊通知超级用户机器人状态变化
+// 离线时优先通过其它在线的机器人发送，恢复在线时由该机器人自己发送
+func (bm *BotManager) notifySuperUsers(selfID int64, online bool, reason string) {
+	text := fmt.Sprintf("机器人 %d 已离线：%s", selfID, reason)
+	if online {
+		text = fmt.Sprintf("机器人 %d 已恢复在线", selfID)
+	}
+
+	var sender *Bot
+	for _, bot := range bm.GetAllBots() {
+		if bot.API == nil {
+			continue
+		}
+		if h, ok := bm.health.get(bot.SelfID); ok && !h.Healthy {
+			continue
+		}
+		if sender == nil || bot.SelfID == selfID {
+			sender = bot
+		}
+	}
+	if sender == nil {
+		logger.Warn("没有可用于通知超级用户的机器人", "selfID", selfID)
+		return
+	}
+
+	for _, su := range bm.config.SuperUsers {
+		if _, err := sender.API.SendPrivateMsg(su, text); err != nil {
+			logger.Warn("通知超级用户失败", "superUser", su, "error", err)
+		}
+	}
+}
+
+// registerHealthMetrics 注册机器人健康状态指标
+func (bm *BotManager) registerHealthMetrics() {
+	metrics.DefaultRegistry.Unregister("xbot_bot_healthy")
+	metrics.DefaultRegistry.MustRegister(metrics.NewGaugeFunc("xbot_bot_healthy",
+		"机器人健康状态（1 健康，0 不健康）", []string{"self_id"},
+		func(observe func(float64, ...string)) {
+			for _, h := range bm.health.snapshot() {
+				value := 0.0
+				if h.Healthy {
+					value = 1
+				}
+				observe(value, strconv.FormatInt(h.SelfID, 10))
+			}
+		}))
+}
+
+// ========== HTTP 探针 ==========
+
+// HealthHandler 返回健康检查处理器（/healthz）
+// 所有机器人健康时返回 200，否则返回 503，响应体包含各机器人的心跳详情
+func (bm *BotManager) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if !bm.IsHealthy() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"healthy": status == http.StatusOK,
+			"bots":    bm.AllBotHealth(),
+		})
+	})
+}
+
+// ReadyHandler 返回就绪检查处理器（/readyz）
+// 就绪时返回 200，否则返回 503
+func (bm *BotManager) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if !bm.IsReady() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"ready": status == http.StatusOK,
+		})
+	})
+}
@@ -0,0 +1,89 @@
+package xbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestHealthMonitor 测试心跳超时与恢复时的状态变化
+func TestHealthMonitor(t *testing.T) {
+	var changes []bool
+	h := newHealthMonitor(0, func(selfID int64, online bool, reason string) {
+		changes = append(changes, online)
+	})
+
+	now := time.Now()
+	hb := &event.HeartbeatMetaEvent{
+		BaseEvent: event.BaseEvent{SelfID: 10000},
+		Status:    types.Status{Online: true, Good: true},
+		Interval:  5000,
+	}
+
+	h.recordHeartbeat(hb, now)
+	if health, ok := h.get(10000); !ok || !health.Healthy {
+		t.Fatalf("Expected healthy bot after heartbeat, got %+v", health)
+	}
+
+	// 3 倍间隔内不视为超时
+	h.check(now.Add(14 * time.Second))
+	if len(changes) != 0 {
+		t.Fatalf("Unexpected status change: %v", changes)
+	}
+
+	h.check(now.Add(16 * time.Second))
+	if health, _ := h.get(10000); health.Healthy {
+		t.Fatal("Expected unhealthy bot after heartbeat timeout")
+	}
+
+	h.recordHeartbeat(hb, now.Add(17*time.Second))
+
+	hb.Status.Online = false
+	h.recordHeartbeat(hb, now.Add(18*time.Second))
+
+	expected := []bool{false, true, false}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected changes %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("Expected changes %v, got %v", expected, changes)
+		}
+	}
+}
+
+// TestHealthMonitorOffline 测试离线通知立即标记为不健康，心跳恢复后重新健康
+func TestHealthMonitorOffline(t *testing.T) {
+	var changes []bool
+	h := newHealthMonitor(0, func(selfID int64, online bool, reason string) {
+		changes = append(changes, online)
+	})
+
+	now := time.Now()
+	hb := &event.HeartbeatMetaEvent{
+		BaseEvent: event.BaseEvent{SelfID: 10000},
+		Status:    types.Status{Online: true, Good: true},
+		Interval:  5000,
+	}
+	h.recordHeartbeat(hb, now)
+
+	if !h.recordOffline(10000, "账号被踢下线") {
+		t.Fatal("Expected offline notice to change status")
+	}
+	if health, _ := h.get(10000); health.Healthy || health.Reason != "账号被踢下线" {
+		t.Fatalf("Expected unhealthy bot after offline notice, got %+v", health)
+	}
+	if h.recordOffline(10000, "账号被踢下线") {
+		t.Error("Expected repeated offline notice not to change status")
+	}
+
+	h.recordHeartbeat(hb, now.Add(time.Second))
+	if health, _ := h.get(10000); !health.Healthy {
+		t.Error("Expected heartbeat to recover the bot")
+	}
+	if len(changes) != 1 || !changes[0] {
+		t.Errorf("Expected only the recovery to be notified, got %v", changes)
+	}
+}
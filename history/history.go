@@ -0,0 +1,318 @@
+package history
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// DefaultSize 每个会话默认保留的消息条数
+const DefaultSize = 100
+
+// DefaultFlushInterval 设置存储后，会话变更写入存储的默认间隔
+const DefaultFlushInterval = 2 * time.Second
+
+// Record 消息记录
+type Record struct {
+	MessageID int64           `json:"message_id"`          // 消息 ID
+	GroupID   int64           `json:"group_id,omitempty"`  // 群号，私聊为 0
+	UserID    int64           `json:"user_id"`             // 发送者 QQ 号
+	Sender    types.Sender    `json:"sender"`              // 发送者信息
+	Time      int64           `json:"time"`                // 发送时间戳
+	Message   message.Message `json:"message"`             // 消息内容
+	FromSelf  bool            `json:"from_self"`           // 是否是机器人自己发送的
+	TargetID  int64           `json:"target_id,omitempty"` // 机器人发送的私聊消息的接收者 QQ 号
+}
+
+// ConversationKey 会话标识，群聊为 group:群号，私聊为 private:对方 QQ 号
+func ConversationKey(groupID, userID int64) string {
+	if groupID != 0 {
+		return "group:" + strconv.FormatInt(groupID, 10)
+	}
+	return "private:" + strconv.FormatInt(userID, 10)
+}
+
+// conversation 单个会话的消息记录，按时间从旧到新排列
+// 消息 ID 只保证在会话内唯一，索引按会话分别维护
+type conversation struct {
+	records []*Record
+	index   map[int64]*Record // 消息 ID -> 记录
+}
+
+// newConversation 创建会话
+func newConversation(records []*Record) *conversation {
+	conv := &conversation{records: records, index: make(map[int64]*Record, len(records))}
+	for _, r := range records {
+		conv.index[r.MessageID] = r
+	}
+	return conv
+}
+
+// History 消息历史
+// 每个会话（群或私聊）保留最近 size 条消息，包括收到的消息和机器人自己发送的消息。
+// 设置存储后，会话记录会持久化，重启后首次访问该会话时恢复；
+// 变更按 flushInterval 批量写入存储，停止前需调用 Flush 写入尚未保存的记录。
+type History struct {
+	size int
+
+	mu            sync.RWMutex
+	conversations map[string]*conversation
+
+	storage       storage.Storage
+	prefix        string
+	flushInterval time.Duration
+	dirty         map[string]struct{} // 尚未写入存储的会话
+	flushTimer    *time.Timer
+}
+
+// New 创建消息历史，size 为每个会话保留的消息条数，为 0 时使用 DefaultSize
+func New(size int) *History {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &History{
+		size:          size,
+		conversations: make(map[string]*conversation),
+		flushInterval: DefaultFlushInterval,
+		dirty:         make(map[string]struct{}),
+	}
+}
+
+// SetStorage 设置持久化存储，prefix 为存储键前缀，用于区分不同的机器人账号
+func (h *History) SetStorage(s storage.Storage, prefix string) *History {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.storage = s
+	h.prefix = prefix
+	return h
+}
+
+// SetFlushInterval 设置写入存储的间隔，<= 0 时每条消息立即写入
+func (h *History) SetFlushInterval(interval time.Duration) *History {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.flushInterval = interval
+	return h
+}
+
+// Add 添加消息记录，会话中已存在的消息 ID 忽略
+func (h *History) Add(r *Record) {
+	key := ConversationKey(r.GroupID, r.conversationUserID())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conv := h.conversation(key)
+	if _, ok := conv.index[r.MessageID]; ok {
+		return
+	}
+	conv.records = append(conv.records, r)
+	conv.index[r.MessageID] = r
+	if n := len(conv.records) - h.size; n > 0 {
+		for _, old := range conv.records[:n] {
+			delete(conv.index, old.MessageID)
+		}
+		conv.records = append([]*Record(nil), conv.records[n:]...)
+	}
+	h.markDirty(key)
+}
+
+// conversationUserID 私聊会话的对方 QQ 号
+func (r *Record) conversationUserID() int64 {
+	if r.FromSelf {
+		return r.TargetID
+	}
+	return r.UserID
+}
+
+// Get 通过消息 ID 在内存中已加载的所有会话中查找记录
+// 不同会话的消息 ID 可能重复，已知会话时应使用 Find
+func (h *History) Get(messageID int64) (*Record, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, conv := range h.conversations {
+		if r, ok := conv.index[messageID]; ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Find 在指定会话中通过消息 ID 获取记录，会话未加载时先从存储恢复
+func (h *History) Find(key string, messageID int64) (*Record, bool) {
+	h.mu.RLock()
+	conv, ok := h.conversations[key]
+	h.mu.RUnlock()
+
+	if !ok {
+		h.mu.Lock()
+		conv = h.conversation(key)
+		h.mu.Unlock()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r, ok := conv.index[messageID]
+	return r, ok
+}
+
+// Recent 获取会话最近的 n 条消息，按时间从旧到新排列；n <= 0 时返回全部
+func (h *History) Recent(key string, n int) []*Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := h.conversation(key).records
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	return append([]*Record(nil), records...)
+}
+
+// Clear 清空会话记录
+func (h *History) Clear(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.conversations[key] = newConversation(nil)
+	delete(h.dirty, key)
+	if h.storage != nil {
+		if err := h.storage.Delete(h.prefix + key); err != nil {
+			logger.Warn("删除消息历史失败", "conversation", key, "error", err)
+		}
+	}
+}
+
+// Flush 立即将尚未保存的会话写入存储
+func (h *History) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.flushTimer != nil {
+		h.flushTimer.Stop()
+		h.flushTimer = nil
+	}
+	for key := range h.dirty {
+		if conv, ok := h.conversations[key]; ok {
+			h.persist(key, conv)
+		}
+	}
+	clear(h.dirty)
+}
+
+// conversation 获取会话，未加载时从存储恢复，调用方需持有写锁
+func (h *History) conversation(key string) *conversation {
+	if conv, ok := h.conversations[key]; ok {
+		return conv
+	}
+
+	var records []*Record
+	if h.storage != nil {
+		data, err := h.storage.Get(h.prefix + key)
+		if err != nil {
+			logger.Warn("加载消息历史失败", "conversation", key, "error", err)
+		} else if data != nil {
+			if err := json.Unmarshal(data, &records); err != nil {
+				logger.Warn("解析消息历史失败", "conversation", key, "error", err)
+			}
+		}
+	}
+	conv := newConversation(records)
+	h.conversations[key] = conv
+	return conv
+}
+
+// markDirty 标记会话需要写入存储，并在 flushInterval 后批量写入，调用方需持有写锁
+func (h *History) markDirty(key string) {
+	if h.storage == nil {
+		return
+	}
+	if h.flushInterval <= 0 {
+		h.persist(key, h.conversations[key])
+		return
+	}
+
+	h.dirty[key] = struct{}{}
+	if h.flushTimer == nil {
+		h.flushTimer = time.AfterFunc(h.flushInterval, h.Flush)
+	}
+}
+
+// persist 持久化会话记录，调用方需持有写锁
+func (h *History) persist(key string, conv *conversation) {
+	data, err := json.Marshal(conv.records)
+	if err != nil {
+		logger.Warn("序列化消息历史失败", "conversation", key, "error", err)
+		return
+	}
+	if err := h.storage.Set(h.prefix+key, data); err != nil {
+		logger.Warn("保存消息历史失败", "conversation", key, "error", err)
+	}
+}
+
+// ========== 事件接入 ==========
+
+// RecordEvent 记录消息事件（包括上报的机器人自身发送的消息），非消息事件忽略
+func (h *History) RecordEvent(evt event.Event) {
+	switch e := evt.(type) {
+	case *event.GroupMessageEvent:
+		h.Add(&Record{
+			MessageID: e.MessageID,
+			GroupID:   e.GroupID,
+			UserID:    e.UserID,
+			Sender:    e.Sender,
+			Time:      e.Time,
+			Message:   e.ParsedMessage,
+		})
+	case *event.PrivateMessageEvent:
+		h.Add(&Record{
+			MessageID: e.MessageID,
+			UserID:    e.UserID,
+			Sender:    e.Sender,
+			Time:      e.Time,
+			Message:   e.ParsedMessage,
+		})
+	case *event.MessageSentEvent:
+		r := &Record{
+			MessageID: e.MessageID,
+			UserID:    e.SelfID,
+			Sender:    e.Sender,
+			Time:      e.Time,
+			Message:   e.ParsedMessage,
+			FromSelf:  true,
+		}
+		if e.IsGroup() {
+			r.GroupID = e.GroupID
+		} else {
+			r.TargetID = e.TargetID
+		}
+		h.Add(r)
+	}
+}
+
+// RecordSent 记录机器人发送的消息，合并转发消息不记录
+// selfID 为机器人 QQ 号，nickname 为记录中使用的发送者昵称
+func (h *History) RecordSent(selfID int64, nickname string, sent api.SentMessage) {
+	if sent.Action != api.ActionSendGroupMsg && sent.Action != api.ActionSendPrivateMsg {
+		return
+	}
+
+	r := &Record{
+		MessageID: sent.MessageID,
+		GroupID:   sent.GroupID,
+		UserID:    selfID,
+		Sender:    types.Sender{UserID: selfID, Nickname: nickname},
+		Time:      time.Now().Unix(),
+		Message:   message.ParseMessage(sent.Message),
+		FromSelf:  true,
+		TargetID:  sent.UserID,
+	}
+	h.Add(r)
+}
@@ -0,0 +1,90 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/storage"
+)
+
+// TestBoundedPerConversation 测试每个会话只保留最近的消息
+func TestBoundedPerConversation(t *testing.T) {
+	h := New(2)
+	for id := int64(1); id <= 3; id++ {
+		h.Add(&Record{MessageID: id, GroupID: 100, UserID: 1})
+	}
+	h.Add(&Record{MessageID: 4, UserID: 1})
+
+	if _, ok := h.Get(1); ok {
+		t.Error("Expected message 1 to be evicted")
+	}
+	recent := h.Recent(ConversationKey(100, 0), 0)
+	if len(recent) != 2 || recent[0].MessageID != 2 || recent[1].MessageID != 3 {
+		t.Errorf("Unexpected group history: %+v", recent)
+	}
+	if recent := h.Recent(ConversationKey(0, 1), 0); len(recent) != 1 {
+		t.Errorf("Expected 1 private message, got %d", len(recent))
+	}
+}
+
+// TestRecordSent 测试记录机器人发送的私聊消息归入对方的会话
+func TestRecordSent(t *testing.T) {
+	h := New(10)
+	h.RecordSent(99, "bot", api.SentMessage{
+		Action:    api.ActionSendPrivateMsg,
+		UserID:    1,
+		MessageID: 5,
+		Message:   []message.MessageSegment{message.Text("hi")},
+	})
+
+	r, ok := h.Find(ConversationKey(0, 1), 5)
+	if !ok {
+		t.Fatal("Expected sent message to be recorded")
+	}
+	if !r.FromSelf || r.UserID != 99 || r.Message.GetPlainText() != "hi" {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+}
+
+// TestPersist 测试从存储恢复会话记录
+func TestPersist(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	saved := New(10).SetStorage(store, "history:1:")
+	saved.Add(&Record{
+		MessageID: 7,
+		GroupID:   100,
+		UserID:    1,
+		Message:   message.Message{message.Text("hello")},
+	})
+	if data, _ := store.Get("history:1:" + ConversationKey(100, 0)); data != nil {
+		t.Error("Expected writes to be batched until flush")
+	}
+	saved.Flush()
+
+	h := New(10).SetStorage(store, "history:1:")
+	if _, ok := h.Get(7); ok {
+		t.Error("Expected conversation not to be loaded before access")
+	}
+	r, ok := h.Find(ConversationKey(100, 0), 7)
+	if !ok || r.Message.GetPlainText() != "hello" {
+		t.Errorf("Expected restored record, got %+v, %v", r, ok)
+	}
+}
+
+// TestSameIDInDifferentConversations 测试不同会话中相同的消息 ID 互不影响
+func TestSameIDInDifferentConversations(t *testing.T) {
+	h := New(10)
+	h.Add(&Record{MessageID: 1, GroupID: 100, UserID: 1, Message: message.Message{message.Text("group")}})
+	h.Add(&Record{MessageID: 1, UserID: 2, Message: message.Message{message.Text("private")}})
+
+	if r, ok := h.Find(ConversationKey(0, 2), 1); !ok || r.Message.GetPlainText() != "private" {
+		t.Errorf("Expected private record, got %+v, %v", r, ok)
+	}
+	if r, ok := h.Find(ConversationKey(100, 0), 1); !ok || r.Message.GetPlainText() != "group" {
+		t.Errorf("Expected group record, got %+v, %v", r, ok)
+	}
+	if _, ok := h.Find(ConversationKey(200, 0), 1); ok {
+		t.Error("Expected no record in another conversation")
+	}
+}
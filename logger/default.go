@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 	"time"
 )
@@ -19,20 +20,34 @@ const (
 	colorGray   = "\033[37m"
 )
 
+// Sink 日志输出目标（输出位置 + 编码格式）
+type Sink struct {
+	Writer  io.Writer
+	Encoder Encoder
+}
+
 // DefaultLogger 默认日志实现
 type DefaultLogger struct {
-	mu     sync.Mutex
-	out    io.Writer
-	level  Level
-	fields map[string]interface{}
+	mu      sync.Mutex
+	sinks   []Sink
+	level   Level
+	fields  map[string]interface{}
+	modules *moduleLevels
 }
 
-// NewDefaultLogger 创建默认日志记录器
+// NewDefaultLogger 创建默认日志记录器（彩色文本格式）
 func NewDefaultLogger(out io.Writer, level Level) *DefaultLogger {
+	return NewSinkLogger(level, Sink{Writer: out, Encoder: &TextEncoder{Color: true}})
+}
+
+// NewSinkLogger 创建输出到多个目标的日志记录器
+// 每个目标可以使用不同的编码格式，例如控制台使用彩色文本、文件使用 JSON
+func NewSinkLogger(level Level, sinks ...Sink) *DefaultLogger {
 	return &DefaultLogger{
-		out:    out,
-		level:  level,
-		fields: make(map[string]interface{}),
+		sinks:   sinks,
+		level:   level,
+		fields:  make(map[string]interface{}),
+		modules: newModuleLevels(),
 	}
 }
 
@@ -43,6 +58,12 @@ func (l *DefaultLogger) SetLevel(level Level) {
 	l.level = level
 }
 
+// SetModuleLevels 设置模块日志级别，例如 {"driver": LevelDebug, "plugin.weather": LevelWarn}
+// 通过 WithField("module", ...) 派生出的日志记录器会使用对应模块的级别
+func (l *DefaultLogger) SetModuleLevels(levels map[string]Level) {
+	l.modules.set(levels)
+}
+
 // Debug 记录 Debug 级别日志
 func (l *DefaultLogger) Debug(msg string, fields ...interface{}) {
 	l.log(LevelDebug, msg, fields...)
@@ -75,9 +96,10 @@ func (l *DefaultLogger) WithField(key string, value interface{}) Logger {
 	newFields[key] = value
 
 	return &DefaultLogger{
-		out:    l.out,
-		level:  l.level,
-		fields: newFields,
+		sinks:   l.sinks,
+		level:   l.level,
+		fields:  newFields,
+		modules: l.modules,
 	}
 }
 
@@ -86,52 +108,48 @@ func (l *DefaultLogger) log(level Level, msg string, fields ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if level < l.level {
+	minLevel := l.level
+	if moduleLevel, ok := l.modules.lookup(l.fields[ModuleField]); ok {
+		minLevel = moduleLevel
+	}
+	if level < minLevel {
 		return
 	}
 
-	// 格式化时间
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	// 选择颜色
-	color := colorReset
-	switch level {
-	case LevelDebug:
-		color = colorGray
-	case LevelInfo:
-		color = colorGreen
-	case LevelWarn:
-		color = colorYellow
-	case LevelError:
-		color = colorRed
+	entry := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  make([]Field, 0, len(l.fields)+len(fields)/2),
 	}
 
-	// 构建日志消息
-	logMsg := fmt.Sprintf("%s[%s]%s [%s] %s",
-		color, level.String(), colorReset, now, msg)
-
-	// 添加默认字段
-	if len(l.fields) > 0 {
-		logMsg += " |"
-		for k, v := range l.fields {
-			logMsg += fmt.Sprintf(" %s=%v", k, v)
-		}
+	// 添加默认字段（按 key 排序，保证输出稳定）
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
 	}
-
-	// 添加传入的字段
-	if len(fields) > 0 {
-		if len(l.fields) == 0 {
-			logMsg += " |"
-		}
-		for i := 0; i < len(fields); i += 2 {
-			if i+1 < len(fields) {
-				logMsg += fmt.Sprintf(" %v=%v", fields[i], fields[i+1])
-			}
-		}
+	sort.Strings(keys)
+	for _, k := range keys {
+		entry.Fields = append(entry.Fields, Field{Key: k, Value: l.fields[k]})
 	}
 
-	logMsg += "\n"
+	// 添加传入的字段
+	entry.Fields = appendKeyValues(entry.Fields, fields)
 
 	// 写入日志
-	l.out.Write([]byte(logMsg))
+	for _, sink := range l.sinks {
+		sink.Writer.Write(sink.Encoder.Encode(entry))
+	}
+}
+
+// appendKeyValues 把 key, value 交替的参数转换为字段
+func appendKeyValues(dst []Field, kvs []interface{}) []Field {
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			key = fmt.Sprint(kvs[i])
+		}
+		dst = append(dst, Field{Key: key, Value: kvs[i+1]})
+	}
+	return dst
 }
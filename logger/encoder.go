@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Field 日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry 日志条目
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  []Field
+}
+
+// Encoder 日志编码器，负责把日志条目编码为一行输出
+type Encoder interface {
+	Encode(entry *Entry) []byte
+}
+
+// Format 日志格式
+type Format string
+
+const (
+	FormatText Format = "text" // 文本格式
+	FormatJSON Format = "json" // JSON 格式
+)
+
+// ParseFormat 解析日志格式，未知格式返回文本格式
+func ParseFormat(s string) Format {
+	switch strings.ToLower(s) {
+	case "json":
+		return FormatJSON
+	default:
+		return FormatText
+	}
+}
+
+// NewEncoder 根据格式创建编码器
+// color 仅对文本格式生效
+func NewEncoder(format Format, color bool) Encoder {
+	if format == FormatJSON {
+		return &JSONEncoder{}
+	}
+	return &TextEncoder{Color: color}
+}
+
+// TextEncoder 文本编码器
+type TextEncoder struct {
+	// Color 是否输出终端颜色，写入文件时应关闭
+	Color bool
+	// TimeLayout 时间格式，默认 "2006-01-02 15:04:05"
+	TimeLayout string
+}
+
+// Encode 编码日志条目
+func (e *TextEncoder) Encode(entry *Entry) []byte {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = "2006-01-02 15:04:05"
+	}
+
+	var buf bytes.Buffer
+	if e.Color {
+		buf.WriteString(levelColor(entry.Level))
+		buf.WriteString("[" + entry.Level.String() + "]")
+		buf.WriteString(colorReset)
+	} else {
+		buf.WriteString("[" + entry.Level.String() + "]")
+	}
+	buf.WriteString(" [" + entry.Time.Format(layout) + "] ")
+	buf.WriteString(entry.Message)
+
+	if len(entry.Fields) > 0 {
+		buf.WriteString(" |")
+		for _, f := range entry.Fields {
+			fmt.Fprintf(&buf, " %s=%v", f.Key, f.Value)
+		}
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// JSONEncoder JSON 编码器，每条日志输出为一行 JSON
+type JSONEncoder struct {
+	// TimeLayout 时间格式，默认 RFC3339（毫秒精度）
+	TimeLayout string
+}
+
+// Encode 编码日志条目
+func (e *JSONEncoder) Encode(entry *Entry) []byte {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = "2006-01-02T15:04:05.000Z07:00"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONPair(&buf, "time", entry.Time.Format(layout))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "level", strings.ToLower(entry.Level.String()))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "msg", entry.Message)
+
+	for _, f := range entry.Fields {
+		key := f.Key
+		// 避免覆盖保留字段
+		if key == "time" || key == "level" || key == "msg" {
+			key = "fields." + key
+		}
+		buf.WriteByte(',')
+		writeJSONPair(&buf, key, f.Value)
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// writeJSONPair 写入一个 JSON 键值对
+func writeJSONPair(buf *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(marshalValue(value))
+}
+
+// marshalValue 序列化字段值，无法序列化时退化为字符串
+func marshalValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return data
+}
+
+// levelColor 获取级别对应的颜色
+func levelColor(level Level) string {
+	switch level {
+	case LevelDebug:
+		return colorGray
+	case LevelInfo:
+		return colorGreen
+	case LevelWarn:
+		return colorYellow
+	case LevelError:
+		return colorRed
+	default:
+		return colorReset
+	}
+}
@@ -62,6 +62,7 @@ type Logger interface {
 
 var (
 	defaultLogger Logger
+	defaultGen    uint64 // 默认日志记录器被替换的次数
 	mu            sync.RWMutex
 )
 
@@ -74,6 +75,7 @@ func SetDefault(l Logger) {
 	mu.Lock()
 	defer mu.Unlock()
 	defaultLogger = l
+	defaultGen++
 }
 
 // GetDefault 获取默认日志记录器
@@ -83,6 +85,14 @@ func GetDefault() Logger {
 	return defaultLogger
 }
 
+// DefaultGeneration 获取默认日志记录器及其版本，每次 SetDefault 版本加一
+// 用于缓存从默认日志记录器派生的日志记录器，版本变化后重新派生
+func DefaultGeneration() (Logger, uint64) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLogger, defaultGen
+}
+
 // Debug 记录 Debug 级别日志
 func Debug(msg string, fields ...interface{}) {
 	GetDefault().Debug(msg, fields...)
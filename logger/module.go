@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ModuleField 模块字段名，通过 WithField(ModuleField, "driver") 标记日志所属模块
+const ModuleField = "module"
+
+// ModuleLeveler 支持按模块设置日志级别的日志记录器
+type ModuleLeveler interface {
+	SetModuleLevels(levels map[string]Level)
+}
+
+// moduleLevels 模块日志级别表，由同一个根日志记录器派生出的所有实例共享
+type moduleLevels struct {
+	mu     sync.RWMutex
+	levels map[string]Level
+}
+
+// newModuleLevels 创建模块日志级别表
+func newModuleLevels() *moduleLevels {
+	return &moduleLevels{levels: make(map[string]Level)}
+}
+
+// set 替换全部模块级别
+func (m *moduleLevels) set(levels map[string]Level) {
+	newLevels := make(map[string]Level, len(levels))
+	for k, v := range levels {
+		newLevels[k] = v
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.levels = newLevels
+}
+
+// lookup 查找模块的日志级别
+// 优先精确匹配，其次按 "." 逐级向上匹配，例如 plugin.weather -> plugin
+func (m *moduleLevels) lookup(module interface{}) (Level, bool) {
+	if m == nil || module == nil {
+		return 0, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.levels) == 0 {
+		return 0, false
+	}
+
+	name := fmt.Sprint(module)
+	for {
+		if level, ok := m.levels[name]; ok {
+			return level, true
+		}
+		idx := strings.LastIndex(name, ".")
+		if idx < 0 {
+			return 0, false
+		}
+		name = name[:idx]
+	}
+}
+
+// ParseModuleLevels 解析配置中的模块日志级别
+func ParseModuleLevels(levels map[string]string) map[string]Level {
+	result := make(map[string]Level, len(levels))
+	for module, level := range levels {
+		result[module] = ParseLevel(level)
+	}
+	return result
+}
+
+// SetModuleLevels 设置默认日志记录器的模块日志级别
+// 默认日志记录器不支持时忽略
+func SetModuleLevels(levels map[string]Level) {
+	if l, ok := GetDefault().(ModuleLeveler); ok {
+		l.SetModuleLevels(levels)
+	}
+}
+
+// Module 获取带模块字段的默认日志记录器
+// 每次调用都基于当前的默认日志记录器，因此 SetDefault 之后依然生效
+func Module(name string) Logger {
+	return GetDefault().WithField(ModuleField, name)
+}
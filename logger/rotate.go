@@ -0,0 +1,309 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 备份文件名中的时间格式
+const backupTimeFormat = "20060102-150405"
+
+// RotateConfig 日志轮转配置
+type RotateConfig struct {
+	// Filename 日志文件路径
+	Filename string
+	// MaxSize 单个文件最大大小（MB），0 表示不按大小轮转
+	MaxSize int
+	// Interval 按时间轮转的间隔，0 表示不按时间轮转
+	Interval time.Duration
+	// MaxBackups 保留的历史文件数量，0 表示全部保留
+	MaxBackups int
+	// Compress 是否使用 gzip 压缩历史文件
+	Compress bool
+}
+
+// RotateWriter 支持按大小/时间轮转的日志文件写入器
+type RotateWriter struct {
+	config   RotateConfig
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// now 获取当前时间，便于测试
+	now func() time.Time
+}
+
+// NewRotateWriter 创建日志轮转写入器
+func NewRotateWriter(config RotateConfig) (*RotateWriter, error) {
+	if config.Filename == "" {
+		return nil, fmt.Errorf("日志文件路径不能为空")
+	}
+
+	w := &RotateWriter{
+		config: config,
+		now:    time.Now,
+	}
+
+	if err := w.openExisting(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// MustCreateRotateWriter 创建日志轮转写入器，失败则退出
+func MustCreateRotateWriter(config RotateConfig) *RotateWriter {
+	w, err := NewRotateWriter(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "无法创建日志文件: %v\n", err)
+		os.Exit(1)
+	}
+	return w
+}
+
+// Write 写入日志，必要时先执行轮转
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即执行一次轮转
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Close 关闭当前日志文件
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// shouldRotate 判断写入 n 字节前是否需要轮转
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.config.MaxSize > 0 && w.size+n > int64(w.config.MaxSize)*1024*1024 {
+		return true
+	}
+	if w.config.Interval > 0 && w.now().Sub(w.openedAt) >= w.config.Interval {
+		return true
+	}
+	return false
+}
+
+// openExisting 打开（或创建）日志文件，并继续追加写入
+func (w *RotateWriter) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(w.config.Filename), 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(w.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("获取日志文件信息失败: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = info.ModTime()
+	if w.size == 0 {
+		w.openedAt = w.now()
+	}
+	return nil
+}
+
+// rotate 关闭当前文件，重命名为备份文件并打开新文件
+func (w *RotateWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("关闭日志文件失败: %w", err)
+		}
+		w.file = nil
+	}
+
+	backup := w.backupName(w.now())
+	if err := os.Rename(w.config.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("重命名日志文件失败: %w", err)
+	}
+
+	file, err := os.OpenFile(w.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("创建日志文件失败: %w", err)
+	}
+	w.file = file
+	w.size = 0
+	w.openedAt = w.now()
+
+	// 压缩和清理在后台执行，避免阻塞写日志
+	go w.postRotate(backup)
+
+	return nil
+}
+
+// backupName 生成备份文件名，例如 logs/bot-20260102-150405.log
+func (w *RotateWriter) backupName(t time.Time) string {
+	dir := filepath.Dir(w.config.Filename)
+	base := filepath.Base(w.config.Filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext)
+
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, t.Format(backupTimeFormat), ext))
+
+	// 同一秒内多次轮转时追加序号
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s-%s.%d%s", prefix, t.Format(backupTimeFormat), i, ext))
+	}
+	return name
+}
+
+// postRotate 压缩备份文件并清理超出数量的历史文件
+func (w *RotateWriter) postRotate(backup string) {
+	if w.config.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "压缩日志文件失败: %v\n", err)
+		}
+	}
+
+	if w.config.MaxBackups > 0 {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err := w.removeOldBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "清理历史日志失败: %v\n", err)
+		}
+	}
+}
+
+// Backups 返回当前存在的历史日志文件，按时间从新到旧排序
+func (w *RotateWriter) Backups() ([]string, error) {
+	dir := filepath.Dir(w.config.Filename)
+	base := filepath.Base(w.config.Filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == base || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		trimmed := strings.TrimSuffix(name, ".gz")
+		if !strings.HasSuffix(trimmed, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(trimmed, prefix), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)]); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+
+	// 文件名包含时间戳，按名称倒序即为从新到旧
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// removeOldBackups 删除超过 MaxBackups 数量的历史文件
+func (w *RotateWriter) removeOldBackups() error {
+	backups, err := w.Backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) <= w.config.MaxBackups {
+		return nil
+	}
+
+	for _, name := range backups[w.config.MaxBackups:] {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFile 将文件压缩为 .gz 并删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
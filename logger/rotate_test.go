@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotateWriterBySize 测试按大小轮转并保留指定数量的历史文件
+func TestRotateWriterBySize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "bot.log")
+
+	w, err := NewRotateWriter(RotateConfig{
+		Filename:   filename,
+		MaxSize:    1,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	// 通过递增的假时间保证备份文件名不同
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	// 等待后台清理完成
+	var backups []string
+	for i := 0; i < 50; i++ {
+		backups, err = w.Backups()
+		if err != nil {
+			t.Fatalf("Backups failed: %v", err)
+		}
+		if len(backups) <= 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 backups, got %d: %v", len(backups), backups)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != int64(len(chunk)) {
+		t.Errorf("Expected current file size %d, got %d", len(chunk), info.Size())
+	}
+}
+
+// TestRotateWriterByInterval 测试按时间轮转与 gzip 压缩
+func TestRotateWriterByInterval(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "bot.log")
+
+	w, err := NewRotateWriter(RotateConfig{
+		Filename: filename,
+		Interval: time.Hour,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRotateWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	w.openedAt = now
+
+	w.Write([]byte("first\n"))
+	now = now.Add(2 * time.Hour)
+	w.Write([]byte("second\n"))
+
+	var backups []string
+	for i := 0; i < 50; i++ {
+		backups, _ = w.Backups()
+		if len(backups) == 1 && strings.HasSuffix(backups[0], ".gz") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("Expected 1 compressed backup, got %v", backups)
+	}
+
+	data, _ := os.ReadFile(filename)
+	if string(data) != "second\n" {
+		t.Errorf("Expected current file to contain only new data, got %q", data)
+	}
+}
+
+// TestJSONEncoder 测试 JSON 编码输出
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSinkLogger(LevelDebug, Sink{Writer: &buf, Encoder: &JSONEncoder{}})
+	l.WithField("module", "driver").Info("hello", "count", 3, "msg", "dup")
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Invalid JSON output %q: %v", buf.String(), err)
+	}
+
+	if out["msg"] != "hello" || out["level"] != "info" {
+		t.Errorf("Unexpected base fields: %v", out)
+	}
+	if out["module"] != "driver" || out["count"] != float64(3) || out["fields.msg"] != "dup" {
+		t.Errorf("Unexpected extra fields: %v", out)
+	}
+}
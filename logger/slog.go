@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ToSlogLevel 将日志级别转换为 slog 级别
+func ToSlogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FromSlogLevel 将 slog 级别转换为日志级别
+func FromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+// ========== Logger -> slog.Handler ==========
+
+// SlogLogger 基于 slog.Handler 的日志记录器
+// 用于把 xbot 的日志接入业务服务已有的 slog 输出
+type SlogLogger struct {
+	handler slog.Handler
+	level   *atomic.Int64
+	module  interface{}
+	modules *moduleLevels
+}
+
+// NewSlogLogger 创建基于 slog.Handler 的日志记录器
+func NewSlogLogger(handler slog.Handler, level Level) *SlogLogger {
+	l := &SlogLogger{
+		handler: handler,
+		level:   &atomic.Int64{},
+		modules: newModuleLevels(),
+	}
+	l.level.Store(int64(level))
+	return l
+}
+
+// SetLevel 设置日志级别
+func (l *SlogLogger) SetLevel(level Level) {
+	l.level.Store(int64(level))
+}
+
+// SetModuleLevels 设置模块日志级别
+func (l *SlogLogger) SetModuleLevels(levels map[string]Level) {
+	l.modules.set(levels)
+}
+
+// Debug 记录 Debug 级别日志
+func (l *SlogLogger) Debug(msg string, fields ...interface{}) {
+	l.log(LevelDebug, msg, fields...)
+}
+
+// Info 记录 Info 级别日志
+func (l *SlogLogger) Info(msg string, fields ...interface{}) {
+	l.log(LevelInfo, msg, fields...)
+}
+
+// Warn 记录 Warn 级别日志
+func (l *SlogLogger) Warn(msg string, fields ...interface{}) {
+	l.log(LevelWarn, msg, fields...)
+}
+
+// Error 记录 Error 级别日志
+func (l *SlogLogger) Error(msg string, fields ...interface{}) {
+	l.log(LevelError, msg, fields...)
+}
+
+// WithField 添加字段
+func (l *SlogLogger) WithField(key string, value interface{}) Logger {
+	module := l.module
+	if key == ModuleField {
+		module = value
+	}
+
+	return &SlogLogger{
+		handler: l.handler.WithAttrs([]slog.Attr{slog.Any(key, value)}),
+		level:   l.level,
+		module:  module,
+		modules: l.modules,
+	}
+}
+
+// log 记录日志
+func (l *SlogLogger) log(level Level, msg string, fields ...interface{}) {
+	minLevel := Level(l.level.Load())
+	if moduleLevel, ok := l.modules.lookup(l.module); ok {
+		minLevel = moduleLevel
+	}
+	if level < minLevel {
+		return
+	}
+
+	ctx := context.Background()
+	slogLevel := ToSlogLevel(level)
+	if !l.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	// 跳过 runtime.Callers、log 和 Debug/Info 等包装函数
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	record := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	record.Add(fields...)
+	l.handler.Handle(ctx, record)
+}
+
+// ========== slog.Handler -> Logger ==========
+
+// slogHandler 基于 Logger 的 slog.Handler
+type slogHandler struct {
+	logger Logger
+	group  string
+}
+
+// NewSlogHandler 创建基于 Logger 的 slog.Handler
+// 用于让使用 slog 的代码输出到 xbot 的日志
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// NewSlog 创建输出到 Logger 的 *slog.Logger
+func NewSlog(l Logger) *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// Enabled 级别过滤交给 Logger 处理
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle 处理日志记录
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]interface{}, 0, r.NumAttrs()*2)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+
+	switch FromSlogLevel(r.Level) {
+	case LevelDebug:
+		h.logger.Debug(r.Message, fields...)
+	case LevelInfo:
+		h.logger.Info(r.Message, fields...)
+	case LevelWarn:
+		h.logger.Warn(r.Message, fields...)
+	default:
+		h.logger.Error(r.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs 添加字段
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []interface{}
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+
+	l := h.logger
+	for i := 0; i+1 < len(fields); i += 2 {
+		l = l.WithField(fields[i].(string), fields[i+1])
+	}
+	return &slogHandler{logger: l, group: h.group}
+}
+
+// WithGroup 添加分组，分组内的字段名以 "group." 为前缀
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr 将 slog.Attr 展开为 key, value 交替的字段
+func appendAttr(fields []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+
+	return append(fields, prefix+a.Key, a.Value.Any())
+}
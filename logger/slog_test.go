@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestModuleLevels 测试按模块覆盖日志级别
+func TestModuleLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSinkLogger(LevelInfo, Sink{Writer: &buf, Encoder: &TextEncoder{}})
+	l.SetModuleLevels(map[string]Level{
+		"driver": LevelDebug,
+		"plugin": LevelWarn,
+	})
+
+	l.WithField(ModuleField, "driver").Debug("driver debug")
+	l.WithField(ModuleField, "plugin.weather").Info("weather info")
+	l.WithField(ModuleField, "plugin.weather").Warn("weather warn")
+	l.Debug("root debug")
+
+	out := buf.String()
+	if !strings.Contains(out, "driver debug") {
+		t.Error("Expected driver debug log to be written")
+	}
+	if strings.Contains(out, "weather info") {
+		t.Error("Expected plugin.weather info log to be filtered by plugin level")
+	}
+	if !strings.Contains(out, "weather warn") {
+		t.Error("Expected plugin.weather warn log to be written")
+	}
+	if strings.Contains(out, "root debug") {
+		t.Error("Expected root debug log to be filtered")
+	}
+}
+
+// TestSlogBridge 测试 Logger 与 slog 的双向桥接
+func TestSlogBridge(t *testing.T) {
+	// Logger -> slog.Handler
+	var slogBuf bytes.Buffer
+	l := NewSlogLogger(slog.NewTextHandler(&slogBuf, &slog.HandlerOptions{Level: slog.LevelDebug}), LevelInfo)
+	l.SetModuleLevels(map[string]Level{"driver": LevelDebug})
+
+	l.WithField(ModuleField, "driver").Debug("via slog", "key", "value")
+	l.Debug("filtered")
+
+	out := slogBuf.String()
+	if !strings.Contains(out, "msg=\"via slog\"") || !strings.Contains(out, "module=driver") || !strings.Contains(out, "key=value") {
+		t.Errorf("Unexpected slog output: %q", out)
+	}
+	if strings.Contains(out, "filtered") {
+		t.Error("Expected debug log without module to be filtered")
+	}
+
+	// slog.Handler -> Logger
+	var buf bytes.Buffer
+	base := NewSinkLogger(LevelDebug, Sink{Writer: &buf, Encoder: &TextEncoder{}})
+	s := NewSlog(base).With("module", "plugin").WithGroup("req")
+	s.Warn("from slog", "id", 1)
+
+	out = buf.String()
+	if !strings.Contains(out, "[WARN]") || !strings.Contains(out, "module=plugin") || !strings.Contains(out, "req.id=1") {
+		t.Errorf("Unexpected logger output: %q", out)
+	}
+}
@@ -0,0 +1,167 @@
+package xbot
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/xiaoyi510/xbot/message"
+)
+
+// ErrNoReplyTarget 事件没有回复目标（如请求事件、元事件），合并转发消息无法发送
+var ErrNoReplyTarget = errors.New("事件没有回复目标")
+
+// LongMessageMode 长消息处理方式
+type LongMessageMode string
+
+const (
+	LongMessageNone    LongMessageMode = "none"    // 不处理，原样发送
+	LongMessageSplit   LongMessageMode = "split"   // 按行切分为多条消息
+	LongMessageForward LongMessageMode = "forward" // 转为合并转发消息
+	LongMessageAuto    LongMessageMode = "auto"    // 分段不超过 MaxSplit 时切分，否则转为合并转发
+)
+
+// LongMessageConfig 长消息处理配置
+// 只对纯文本消息生效，包含图片、@ 等其它消息段的消息原样发送
+type LongMessageConfig struct {
+	Mode      LongMessageMode // 处理方式
+	MaxLength int             // 单条消息的最大字符数，超过时视为长消息，默认 1000
+	MaxSplit  int             // auto 模式下最多切分的消息条数，默认 3
+}
+
+// withDefaults 填充默认值
+func (c LongMessageConfig) withDefaults() LongMessageConfig {
+	if c.Mode == "" {
+		c.Mode = LongMessageNone
+	}
+	if c.MaxLength <= 0 {
+		c.MaxLength = 1000
+	}
+	if c.MaxSplit <= 0 {
+		c.MaxSplit = 3
+	}
+	return c
+}
+
+// ReplyOption 回复选项
+type ReplyOption func(*LongMessageConfig)
+
+// SplitLongMessage 超过 maxLength 个字符的文本按行切分为多条消息发送
+func SplitLongMessage(maxLength int) ReplyOption {
+	return func(c *LongMessageConfig) {
+		c.Mode = LongMessageSplit
+		c.MaxLength = maxLength
+	}
+}
+
+// ForwardLongMessage 超过 maxLength 个字符的文本转为合并转发消息发送
+func ForwardLongMessage(maxLength int) ReplyOption {
+	return func(c *LongMessageConfig) {
+		c.Mode = LongMessageForward
+		c.MaxLength = maxLength
+	}
+}
+
+// AutoLongMessage 超过 maxLength 个字符的文本切分发送，切分后超过 maxSplit 条时转为合并转发
+func AutoLongMessage(maxLength, maxSplit int) ReplyOption {
+	return func(c *LongMessageConfig) {
+		c.Mode = LongMessageAuto
+		c.MaxLength = maxLength
+		c.MaxSplit = maxSplit
+	}
+}
+
+// WithLongMessage 使用指定的长消息处理配置
+func WithLongMessage(config LongMessageConfig) ReplyOption {
+	return func(c *LongMessageConfig) {
+		*c = config
+	}
+}
+
+// longMessageText 获取需要按长消息处理的文本，非纯文本消息返回 false
+func longMessageText(msg interface{}) (string, bool) {
+	switch m := msg.(type) {
+	case string:
+		return m, true
+	case message.Message:
+		if m.IsPlainText() {
+			return m.GetPlainText(), true
+		}
+	case []message.MessageSegment:
+		if message.Message(m).IsPlainText() {
+			return message.Message(m).GetPlainText(), true
+		}
+	case message.MessageSegment:
+		if m.Type == "text" {
+			return message.Message{m}.GetPlainText(), true
+		}
+	}
+	return "", false
+}
+
+// replyLong 按配置发送长消息
+// 返回 handled 为 false 表示消息不需要特殊处理，由调用方正常发送
+func (ctx *Context) replyLong(msg interface{}, config LongMessageConfig) (messageID int64, handled bool, err error) {
+	config = config.withDefaults()
+	if config.Mode == LongMessageNone {
+		return 0, false, nil
+	}
+
+	text, ok := longMessageText(msg)
+	if !ok {
+		return 0, false, nil
+	}
+
+	parts := message.SplitText(text, config.MaxLength)
+	if len(parts) <= 1 {
+		return 0, false, nil
+	}
+
+	if config.Mode == LongMessageForward || config.Mode == LongMessageAuto && len(parts) > config.MaxSplit {
+		messageID, err = ctx.replyForward(parts)
+		return messageID, true, err
+	}
+
+	// 逐条发送，返回第一条消息的 ID
+	for i, part := range parts {
+		id, err := ctx.reply(part)
+		if err != nil {
+			return messageID, true, err
+		}
+		if i == 0 {
+			messageID = id
+		}
+	}
+	return messageID, true, nil
+}
+
+// replyForward 将多段文本作为合并转发消息回复
+func (ctx *Context) replyForward(parts []string) (int64, error) {
+	groupID, userID, ok := ctx.replyTarget()
+	if !ok {
+		return 0, ErrNoReplyTarget
+	}
+
+	nickname := "xbot"
+	if ctx.Bot != nil && ctx.Bot.Config != nil && len(ctx.Bot.Config.Nickname) > 0 {
+		nickname = ctx.Bot.Config.Nickname[0]
+	}
+
+	nodes := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		content := []message.MessageSegment{message.Text(strings.TrimSpace(part))}
+		nodes = append(nodes, message.CustomNode(ctx.Event.GetSelfID(), nickname, content))
+	}
+
+	if groupID != 0 {
+		resp, err := ctx.API().SendGroupForwardMsg(groupID, nodes)
+		if err != nil {
+			return 0, err
+		}
+		return resp.Data.MessageID, nil
+	}
+	resp, err := ctx.API().SendPrivateForwardMsg(userID, nodes)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Data.MessageID, nil
+}
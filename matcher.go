@@ -2,29 +2,49 @@ package xbot
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/xiaoyi510/xbot/metrics"
+
 	"github.com/dlclark/regexp2"
 )
 
 // Matcher 匹配器
 type Matcher struct {
-	priority    int
+	id          int64        // 匹配器 ID，全局唯一
+	name        string       // 匹配器名称，用于日志和指标
+	pattern     string       // 匹配内容（关键词、正则等），只用于日志和管理后台，不作为指标标签
+	priority    atomic.Int64 // 优先级，运行时可修改，分发时在其他 goroutine 中读取
 	filters     []Filter
 	limiter     Limiter
-	handler     interface{}
+	handler     handlerInvoker
+	accepts     func(*Context) bool // 处理函数要求的事件类型检查，为 nil 时不检查
+	engine      *Engine             // 所属引擎，用于注入插件存储
 	middlewares []func(next func(*Context)) func(*Context)
 	matchFunc   func(*Context) bool
 	block       bool // 是否阻止继续匹配，默认为false（继续匹配）
+
+	disabled atomic.Bool  // 是否已禁用
+	removed  atomic.Bool  // 是否已从引擎移除
+	maxHits  atomic.Int64 // 匹配成功的次数上限，为 0 时不限制
+	hits     atomic.Int64 // 匹配成功的次数
+	expireAt atomic.Int64 // 过期时间（UnixNano），为 0 时不过期；注册后仍可设置，因此与 Match 并发访问
+
+	timerMu     sync.Mutex
+	expireTimer *time.Timer // ExpireAfter 设置的移除定时器
 }
 
+// matcherSeq 匹配器 ID 序列
+var matcherSeq atomic.Int64
+
 // newMatcher 创建匹配器
-func newMatcher(matchFunc func(*Context) bool, filters ...Filter) *Matcher {
+func newMatcher(name string, matchFunc func(*Context) bool, filters ...Filter) *Matcher {
 	return &Matcher{
-		priority:    0,
+		id:          matcherSeq.Add(1),
+		name:        name,
 		filters:     filters,
 		matchFunc:   matchFunc,
 		middlewares: make([]func(next func(*Context)) func(*Context), 0),
@@ -52,14 +72,130 @@ func (m *Matcher) LimitWithRedis(limiter Limiter) *Matcher {
 }
 
 // Handle 设置处理函数
+// 处理函数的参数按类型注入（*Context、具体事件、*RegexMatch、*api.Client、message.Message、
+// storage.Storage、命令参数结构体等，详见 handler.go），可以返回 error；
+// 签名不受支持时在注册时 panic，例如 func(ctx *Context, e *event.GroupIncreaseNoticeEvent) error
 func (m *Matcher) Handle(handler interface{}) *Matcher {
-	m.handler = handler
+	invoker, err := compileHandler(handler)
+	if err != nil {
+		panic(fmt.Sprintf("xbot: 匹配器 %s 的处理函数无效：%v", m.name, err))
+	}
+	m.handler = invoker
+	m.accepts = handlerEventGuard(handler)
+	return m
+}
+
+// Name 设置匹配器名称
+// 名称用于日志和指标（如 xbot_matcher_hits_total 的 matcher 标签），应保持简短且数量有限；
+// 默认名称由匹配方式生成，例如 "command:echo"，关键词、正则等匹配方式使用 "regex#12" 这样的方式加 ID，
+// 匹配内容通过 GetPattern 获取
+func (m *Matcher) Name(name string) *Matcher {
+	m.name = name
+	return m
+}
+
+// GetName 获取匹配器名称
+func (m *Matcher) GetName() string {
+	return m.name
+}
+
+// GetPattern 获取匹配内容，例如关键词列表或正则表达式，没有时返回空字符串
+func (m *Matcher) GetPattern() string {
+	return m.pattern
+}
+
+// ID 获取匹配器 ID，可用于 Engine.RemoveByID
+func (m *Matcher) ID() int64 {
+	return m.id
+}
+
+// Disable 禁用匹配器，禁用后不再匹配任何事件
+func (m *Matcher) Disable() *Matcher {
+	m.disabled.Store(true)
+	return m
+}
+
+// Enable 启用匹配器
+func (m *Matcher) Enable() *Matcher {
+	m.disabled.Store(false)
+	return m
+}
+
+// IsEnabled 匹配器是否启用，已移除的匹配器返回 false
+func (m *Matcher) IsEnabled() bool {
+	return !m.disabled.Load() && !m.removed.Load()
+}
+
+// Times 设置为临时匹配器，匹配成功 n 次后自动从引擎移除
+func (m *Matcher) Times(n int) *Matcher {
+	m.maxHits.Store(int64(n))
+	return m
+}
+
+// ExpireAfter 设置为临时匹配器，经过 d 后自动从引擎移除
+// 多次调用时以最后一次为准
+func (m *Matcher) ExpireAfter(d time.Duration) *Matcher {
+	m.timerMu.Lock()
+	defer m.timerMu.Unlock()
+
+	if m.expireTimer != nil {
+		m.expireTimer.Stop()
+	}
+	m.expireAt.Store(time.Now().Add(d).UnixNano())
+
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		// 已被之后的 ExpireAfter 替换的定时器不再移除匹配器
+		m.timerMu.Lock()
+		current := m.expireTimer == t
+		m.timerMu.Unlock()
+		if current {
+			m.remove()
+		}
+	})
+	m.expireTimer = t
 	return m
 }
 
+// stopExpireTimer 停止 ExpireAfter 设置的定时器
+func (m *Matcher) stopExpireTimer() {
+	m.timerMu.Lock()
+	defer m.timerMu.Unlock()
+	if m.expireTimer != nil {
+		m.expireTimer.Stop()
+		m.expireTimer = nil
+	}
+}
+
+// Remove 从所属引擎移除匹配器
+func (m *Matcher) Remove() {
+	m.remove()
+}
+
+// remove 从所属引擎移除匹配器
+func (m *Matcher) remove() {
+	m.stopExpireTimer()
+	if m.engine != nil {
+		m.engine.Remove(m)
+	} else {
+		m.removed.Store(true)
+	}
+}
+
+// GetPriority 获取优先级
+func (m *Matcher) GetPriority() int {
+	return int(m.priority.Load())
+}
+
+// IsBlock 是否阻止继续匹配
+func (m *Matcher) IsBlock() bool {
+	return m.block
+}
+
 // Priority 设置优先级
 func (m *Matcher) Priority(p int) *Matcher {
-	m.priority = p
+	m.priority.Store(int64(p))
+	matchersVersion.Add(1)
 	return m
 }
 
@@ -82,11 +218,24 @@ func (m *Matcher) SetBlock(block ...bool) *Matcher {
 
 // Match 判断是否匹配
 func (m *Matcher) Match(ctx *Context) bool {
+	if !m.IsEnabled() {
+		return false
+	}
+	if expireAt := m.expireAt.Load(); expireAt != 0 && time.Now().UnixNano() > expireAt {
+		m.remove()
+		return false
+	}
+
 	// 先检查匹配函数
 	if m.matchFunc != nil && !m.matchFunc(ctx) {
 		return false
 	}
 
+	// 处理函数要求的事件类型不符时不匹配，避免不会执行的处理函数消耗次数或阻止后续匹配器
+	if m.accepts != nil && !m.accepts(ctx) {
+		return false
+	}
+
 	// 再检查过滤器
 	for _, filter := range m.filters {
 		if !filter(ctx) {
@@ -101,6 +250,8 @@ func (m *Matcher) Match(ctx *Context) bool {
 		key := generateLimiterKey(userID, groupID)
 
 		if !m.limiter.Allow(key) {
+			metrics.LimiterRejections.Inc(m.name)
+
 			// 触发限流回调
 			if limiter, ok := m.limiter.(*SlidingWindowLimiter); ok && limiter.onExceed != nil {
 				limiter.onExceed(ctx)
@@ -111,6 +262,17 @@ func (m *Matcher) Match(ctx *Context) bool {
 		}
 	}
 
+	// 临时匹配器：达到次数上限后移除
+	if maxHits := m.maxHits.Load(); maxHits > 0 {
+		hits := m.hits.Add(1)
+		if hits > maxHits {
+			return false
+		}
+		if hits == maxHits {
+			m.remove()
+		}
+	}
+
 	return true
 }
 
@@ -121,10 +283,9 @@ func (m *Matcher) Execute(ctx *Context) {
 	}
 
 	// 应用中间件
+	var err error
 	handler := func(ctx *Context) {
-		// 使用反射调用处理函数
-		handlerValue := reflect.ValueOf(m.handler)
-		handlerValue.Call([]reflect.Value{reflect.ValueOf(ctx)})
+		err = m.handler(ctx, m)
 	}
 
 	// 从后向前应用中间件
@@ -134,6 +295,10 @@ func (m *Matcher) Execute(ctx *Context) {
 
 	// 执行
 	handler(ctx)
+
+	if err != nil {
+		m.handleError(ctx, err)
+	}
 }
 
 // generateLimiterKey 生成限流 key
@@ -0,0 +1,118 @@
+package xbot
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestMatcherLifecycle 测试匹配器的禁用、移除和临时匹配器
+func TestMatcherLifecycle(t *testing.T) {
+	bot := &Bot{Config: &Config{}}
+	e := &Engine{name: "lifecycle", bot: bot}
+	ctx := NewContext(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		ParsedMessage: message.Message{message.Text("hi")},
+	}, bot)
+
+	m := e.OnKeywords([]string{"hi"})
+	if m.Disable().Match(ctx) {
+		t.Error("Expected disabled matcher not to match")
+	}
+	if !m.Enable().Match(ctx) {
+		t.Error("Expected enabled matcher to match")
+	}
+
+	if e.MatcherByID(m.ID()) != m || !e.RemoveByID(m.ID()) {
+		t.Fatal("Expected matcher to be found and removed by ID")
+	}
+	if len(e.Matchers()) != 0 || m.Match(ctx) || m.Enable().IsEnabled() {
+		t.Error("Expected removed matcher to stay inactive")
+	}
+
+	// 匹配 2 次后自动移除
+	once := e.OnKeywords([]string{"hi"}).Times(2)
+	if !once.Match(ctx) || !once.Match(ctx) || once.Match(ctx) {
+		t.Error("Expected temporary matcher to match exactly twice")
+	}
+	if e.MatcherByID(once.ID()) != nil {
+		t.Error("Expected temporary matcher to be removed after its hits")
+	}
+
+	// 过期后自动移除
+	expiring := e.OnKeywords([]string{"hi"}).ExpireAfter(10 * time.Millisecond)
+	if !expiring.Match(ctx) {
+		t.Error("Expected matcher to match before expiring")
+	}
+	// 轮询直到过期，避免固定等待时间在繁忙的机器上不够
+	deadline := time.Now().Add(time.Second)
+	for expiring.Match(ctx) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if expiring.Match(ctx) || e.MatcherByID(expiring.ID()) != nil {
+		t.Error("Expected expired matcher to be removed")
+	}
+}
+
+// TestMatcherOptionsDuringMatch 测试注册后设置 Times 和 ExpireAfter 与并发的 Match 不产生数据竞争
+func TestMatcherOptionsDuringMatch(t *testing.T) {
+	bot := &Bot{Config: &Config{}}
+	e := &Engine{name: "options", bot: bot}
+	ctx := NewContext(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		ParsedMessage: message.Message{message.Text("hi")},
+	}, bot)
+
+	m := e.OnKeywords([]string{"hi"})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			m.Match(ctx)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		m.Times(1000).ExpireAfter(time.Minute)
+	}()
+	wg.Wait()
+
+	if !m.Match(ctx) {
+		t.Error("Expected matcher to match within its limits")
+	}
+}
+
+// TestMatcherExpireAfterReplace 测试重复调用 ExpireAfter 时替换之前的定时器，移除后定时器停止
+func TestMatcherExpireAfterReplace(t *testing.T) {
+	e := &Engine{name: "expire"}
+	m := e.OnMessage().ExpireAfter(10 * time.Millisecond).ExpireAfter(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if e.MatcherByID(m.ID()) == nil {
+		t.Fatal("Expected replaced timer not to remove the matcher")
+	}
+
+	m.Remove()
+	m.timerMu.Lock()
+	defer m.timerMu.Unlock()
+	if m.expireTimer != nil {
+		t.Error("Expected expiry timer to be stopped on Remove")
+	}
+}
+
+// TestMatcherDefaultName 测试按内容匹配的匹配器名称不包含匹配内容，避免指标标签过多
+func TestMatcherDefaultName(t *testing.T) {
+	e := &Engine{name: "names"}
+	m := e.OnRegex(`^天气 (\w+)$`)
+	if m.GetName() != fmt.Sprintf("regex#%d", m.ID()) || m.GetPattern() != `^天气 (\w+)$` {
+		t.Errorf("Unexpected name %q pattern %q", m.GetName(), m.GetPattern())
+	}
+	if c := e.OnCommand("echo"); c.GetName() != "command:echo" {
+		t.Errorf("Expected command name to be kept, got %q", c.GetName())
+	}
+}
@@ -0,0 +1,59 @@
+package message
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitText 将长文本按行切分为多段，每段不超过 maxLen 个字符
+// 优先在换行处切分；单行超过 maxLen 时按字符强制切分。maxLen <= 0 时不切分
+func SplitText(text string, maxLen int) []string {
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	currentLen := 0
+
+	flush := func() {
+		if part := strings.Trim(current.String(), "\n"); part != "" {
+			parts = append(parts, part)
+		}
+		current.Reset()
+		currentLen = 0
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineLen := utf8.RuneCountInString(line)
+
+		// 当前段放不下这一行，先结束当前段
+		if currentLen > 0 && currentLen+lineLen > maxLen {
+			flush()
+		}
+
+		// 单行过长，按字符强制切分
+		for lineLen > maxLen {
+			runes := []rune(line)
+			parts = append(parts, string(runes[:maxLen]))
+			line = string(runes[maxLen:])
+			lineLen -= maxLen
+		}
+
+		current.WriteString(line)
+		currentLen += lineLen
+	}
+	flush()
+
+	return parts
+}
+
+// IsPlainText 消息是否只包含文本消息段
+func (m Message) IsPlainText() bool {
+	for _, seg := range m {
+		if seg.Type != "text" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package message
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestSplitText 测试按行切分长文本
+func TestSplitText(t *testing.T) {
+	parts := SplitText("第一行\n第二行\n第三行", 8)
+	if len(parts) != 2 || parts[0] != "第一行\n第二行" || parts[1] != "第三行" {
+		t.Errorf("Unexpected parts: %q", parts)
+	}
+
+	long := strings.Repeat("字", 25)
+	parts = SplitText(long+"\nend", 10)
+	if len(parts) != 3 || parts[2] != "字字字字字\nend" {
+		t.Errorf("Unexpected parts for long line: %q", parts)
+	}
+	for _, p := range parts {
+		if utf8.RuneCountInString(p) > 10 {
+			t.Errorf("Part exceeds limit: %q", p)
+		}
+	}
+
+	if parts := SplitText("short", 10); len(parts) != 1 || parts[0] != "short" {
+		t.Errorf("Expected text unchanged, got %q", parts)
+	}
+}
@@ -0,0 +1,460 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector 指标采集器，负责以 Prometheus 文本格式输出自身
+type Collector interface {
+	// Name 指标名称
+	Name() string
+	// Write 以 Prometheus 文本格式写出指标
+	Write(w io.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]Collector),
+	}
+}
+
+// DefaultRegistry 默认指标注册表，框架内置指标都注册在这里
+var DefaultRegistry = NewRegistry()
+
+// Register 注册采集器，同名采集器已存在时返回错误
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf("指标 %s 已注册", c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister 注册采集器，失败则 panic
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister 注销采集器
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.collectors, name)
+}
+
+// WriteText 以 Prometheus 文本格式写出所有指标
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]Collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.Write(bw)
+	}
+	return bw.Flush()
+}
+
+// ========== Counter ==========
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.RWMutex
+	values map[string]*sample
+}
+
+// sample 一组标签对应的值
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec 创建带标签的计数器
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*sample),
+	}
+}
+
+// Name 指标名称
+func (c *CounterVec) Name() string {
+	return c.name
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 v（v 不能为负数）
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	key := labelKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// Get 获取当前计数
+func (c *CounterVec) Get(labelValues ...string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s, ok := c.values[labelKey(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Write 以 Prometheus 文本格式写出指标
+func (c *CounterVec) Write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, s := range sortedSamples(c.values) {
+		writeSample(w, c.name, c.labels, s.labelValues, nil, s.value)
+	}
+}
+
+// ========== Gauge ==========
+
+// GaugeVec 带标签的仪表盘
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.RWMutex
+	values map[string]*sample
+}
+
+// NewGaugeVec 创建带标签的仪表盘
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*sample),
+	}
+}
+
+// Name 指标名称
+func (g *GaugeVec) Name() string {
+	return g.name
+}
+
+// Set 设置值
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *sample) { s.value = v })
+}
+
+// Inc 加一
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec 减一
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Add 增加 v（可以为负数）
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.update(labelValues, func(s *sample) { s.value += v })
+}
+
+// Get 获取当前值
+func (g *GaugeVec) Get(labelValues ...string) float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if s, ok := g.values[labelKey(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// update 更新一组标签对应的值
+func (g *GaugeVec) update(labelValues []string, fn func(s *sample)) {
+	key := labelKey(labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s, ok := g.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		g.values[key] = s
+	}
+	fn(s)
+}
+
+// Write 以 Prometheus 文本格式写出指标
+func (g *GaugeVec) Write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, s := range sortedSamples(g.values) {
+		writeSample(w, g.name, g.labels, s.labelValues, nil, s.value)
+	}
+}
+
+// GaugeFunc 采集时才计算值的仪表盘
+// 适用于协程数、连接状态等可以随时读取的值
+type GaugeFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func(observe func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc 创建采集时计算的仪表盘
+// collect 在每次采集时被调用，通过 observe 上报每组标签的值
+func NewGaugeFunc(name, help string, labels []string, collect func(observe func(value float64, labelValues ...string))) *GaugeFunc {
+	return &GaugeFunc{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		collect: collect,
+	}
+}
+
+// Name 指标名称
+func (g *GaugeFunc) Name() string {
+	return g.name
+}
+
+// Write 以 Prometheus 文本格式写出指标
+func (g *GaugeFunc) Write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	g.collect(func(value float64, labelValues ...string) {
+		writeSample(w, g.name, g.labels, labelValues, nil, value)
+	})
+}
+
+// ========== Histogram ==========
+
+// DefBuckets 默认直方图分桶（秒）
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.RWMutex
+	values  map[string]*histogramSample
+}
+
+// histogramSample 一组标签对应的直方图数据
+type histogramSample struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+// NewHistogramVec 创建带标签的直方图，buckets 为空时使用 DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: sorted,
+		values:  make(map[string]*histogramSample),
+	}
+}
+
+// Name 指标名称
+func (h *HistogramVec) Name() string {
+	return h.name
+}
+
+// Observe 记录一个观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSample{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Count 获取观测次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if s, ok := h.values[labelKey(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// Write 以 Prometheus 文本格式写出指标
+func (h *HistogramVec) Write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := h.values[k]
+		for i, upper := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, []string{"le", formatFloat(upper)}, float64(s.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, []string{"le", "+Inf"}, float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, nil, s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, nil, float64(s.count))
+	}
+}
+
+// ========== 文本格式辅助函数 ==========
+
+// labelKey 生成标签值的唯一 key
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// sortedSamples 按标签排序，保证输出稳定
+func sortedSamples(values map[string]*sample) []*sample {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	samples := make([]*sample, 0, len(keys))
+	for _, k := range keys {
+		samples = append(samples, values[k])
+	}
+	return samples
+}
+
+// writeHeader 写出 HELP 和 TYPE 行
+func writeHeader(w io.Writer, name, help, typ string) {
+	if help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// writeSample 写出一行样本
+// extra 为额外的标签键值对（如直方图的 le）
+func writeSample(w io.Writer, name string, labels, labelValues, extra []string, value float64) {
+	io.WriteString(w, name)
+
+	pairs := make([]string, 0, len(labels)+1)
+	for i, label := range labels {
+		v := ""
+		if i < len(labelValues) {
+			v = labelValues[i]
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label, escapeLabel(v)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) > 0 {
+		io.WriteString(w, "{"+strings.Join(pairs, ",")+"}")
+	}
+
+	io.WriteString(w, " "+formatFloat(value)+"\n")
+}
+
+// formatFloat 格式化浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeLabel 转义标签值
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// escapeHelp 转义帮助文本
+func escapeHelp(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestRegistryWriteText 测试 Prometheus 文本格式输出
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+
+	counter := NewCounterVec("test_events_total", "事件总数", "post_type")
+	gauge := NewGaugeVec("test_in_flight", "")
+	histogram := NewHistogramVec("test_duration_seconds", "耗时", []float64{0.1, 1}, "action")
+	r.MustRegister(counter, gauge, histogram)
+
+	counter.Inc("message")
+	counter.Add(2, "message")
+	counter.Inc("notice\"x")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+	histogram.Observe(0.05, "send_msg")
+	histogram.Observe(0.5, "send_msg")
+	histogram.Observe(3, "send_msg")
+
+	if err := r.Register(NewCounterVec("test_events_total", "")); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# HELP test_events_total 事件总数\n# TYPE test_events_total counter\n",
+		`test_events_total{post_type="message"} 3`,
+		`test_events_total{post_type="notice\"x"} 1`,
+		"# TYPE test_in_flight gauge\ntest_in_flight 1\n",
+		`test_duration_seconds_bucket{action="send_msg",le="0.1"} 1`,
+		`test_duration_seconds_bucket{action="send_msg",le="1"} 2`,
+		`test_duration_seconds_bucket{action="send_msg",le="+Inf"} 3`,
+		`test_duration_seconds_sum{action="send_msg"} 3.55`,
+		`test_duration_seconds_count{action="send_msg"} 3`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected output to contain %q, got:\n%s", e, out)
+		}
+	}
+}
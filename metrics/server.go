@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/xiaoyi510/xbot/logger"
+)
+
+// Handler 返回输出默认注册表指标的 HTTP 处理器
+func Handler() http.Handler {
+	return HandlerFor(DefaultRegistry)
+}
+
+// HandlerFor 返回输出指定注册表指标的 HTTP 处理器
+func HandlerFor(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			logger.Warn("输出指标失败", "error", err)
+		}
+	})
+}
+
+// Server 指标 HTTP 服务
+type Server struct {
+	server *http.Server
+	mux    *http.ServeMux
+}
+
+// NewServer 创建指标 HTTP 服务
+// path 为空时默认使用 /metrics
+func NewServer(addr, path string) *Server {
+	if path == "" {
+		path = "/metrics"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler())
+
+	return &Server{
+		server: &http.Server{
+			Addr:         addr,
+			Handler:      mux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		mux: mux,
+	}
+}
+
+// Handle 在指标服务上挂载额外的处理器（如健康检查），需在 Start 之前调用
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start 在后台启动 HTTP 服务
+func (s *Server) Start() {
+	go func() {
+		logger.Info("指标服务启动", "addr", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("指标服务启动失败", "error", err)
+		}
+	}()
+}
+
+// Close 关闭 HTTP 服务
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"runtime"
+)
+
+// 框架内置指标
+var (
+	// EventsReceived 收到的事件数，按 post_type 区分
+	EventsReceived = NewCounterVec("xbot_events_received_total",
+		"收到的事件总数", "post_type")
+
+	// MatcherHits 匹配器命中次数，按匹配器名称区分
+	MatcherHits = NewCounterVec("xbot_matcher_hits_total",
+		"匹配器命中总数", "matcher")
+
+	// HandlerDuration 处理函数执行耗时，按匹配器名称区分
+	HandlerDuration = NewHistogramVec("xbot_handler_duration_seconds",
+		"处理函数执行耗时（秒）", nil, "matcher")
+
+	// HandlersInFlight 正在执行的处理函数数量
+	HandlersInFlight = NewGaugeVec("xbot_handlers_in_flight",
+		"正在执行的处理函数数量")
+
+	// SendQueueDepth 发送队列中排队的消息数，按优先级（low/normal/high）区分，多个账号的队列累加
+	SendQueueDepth = NewGaugeVec("xbot_send_queue_depth",
+		"发送队列中排队的消息数", "lane")
+
+	// APICalls API 调用次数，按 action 和 retcode 区分
+	// 调用未得到响应（如超时、未连接）时 retcode 为 "error"
+	APICalls = NewCounterVec("xbot_api_calls_total",
+		"API 调用总数", "action", "retcode")
+
+	// APIFailures API 调用失败次数，按 action 和 retcode 区分
+	APIFailures = NewCounterVec("xbot_api_call_failures_total",
+		"API 调用失败总数", "action", "retcode")
+
+	// APIDuration API 调用耗时，按 action 区分
+	APIDuration = NewHistogramVec("xbot_api_call_duration_seconds",
+		"API 调用耗时（秒）", nil, "action")
+
+	// APIRetries API 重试次数（包括等待重连后的重发），按 action 区分
+	APIRetries = NewCounterVec("xbot_api_call_retries_total",
+		"API 重试总数", "action")
+
+	// LimiterRejections 限流拒绝次数，按匹配器名称区分
+	LimiterRejections = NewCounterVec("xbot_limiter_rejections_total",
+		"限流拒绝总数", "matcher")
+
+	// DriverReconnects 驱动器重连次数，按驱动器类型区分
+	DriverReconnects = NewCounterVec("xbot_driver_reconnects_total",
+		"驱动器重连总数", "driver")
+
+	// Goroutines 当前协程数
+	Goroutines = NewGaugeFunc("xbot_goroutines",
+		"当前协程数", nil, func(observe func(float64, ...string)) {
+			observe(float64(runtime.NumGoroutine()))
+		})
+)
+
+func init() {
+	DefaultRegistry.MustRegister(
+		EventsReceived,
+		MatcherHits,
+		HandlerDuration,
+		HandlersInFlight,
+		SendQueueDepth,
+		APICalls,
+		APIFailures,
+		APIDuration,
+		APIRetries,
+		LimiterRejections,
+		DriverReconnects,
+		Goroutines,
+	)
+}
@@ -0,0 +1,621 @@
+package xbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/logger"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// RequestAction 请求处理方式
+type RequestAction string
+
+const (
+	RequestActionApprove RequestAction = "approve" // 同意
+	RequestActionReject  RequestAction = "reject"  // 拒绝
+	RequestActionManual  RequestAction = "manual"  // 转交审核人处理
+	RequestActionIgnore  RequestAction = "ignore"  // 不处理，交给匹配器
+)
+
+// DefaultPendingTimeout 待审核请求的默认超时时间
+const DefaultPendingTimeout = 24 * time.Hour
+
+// RequestRule 请求处理规则
+// 配置了 Answer 或 MinLevel 且全部满足时自动同意，否则按 Fallback 处理；
+// 没有配置任何条件时直接按 Fallback 处理
+type RequestRule struct {
+	Answer       string        // 验证信息需匹配的正则表达式，加群问题的验证信息格式通常为 "问题：xxx\n答案：xxx"
+	MinLevel     int           // 申请人最低 QQ 等级，为 0 时不检查
+	Fallback     RequestAction // 不满足条件时的处理方式，默认转交审核人
+	RejectReason string        // 拒绝理由，黑名单和自动拒绝时使用
+	Admins       []int64       // 审核人，为空时使用超级用户
+	NotifyGroup  int64         // 审核通知发送到的群，为 0 时私聊通知审核人
+}
+
+// RequestPolicyConfig 加好友、加群请求自动处理配置
+type RequestPolicyConfig struct {
+	Friend                RequestRule           // 加好友请求规则
+	Group                 RequestRule           // 加群请求的默认规则
+	Groups                map[int64]RequestRule // 按群号单独配置的加群请求规则
+	AcceptSuperUserInvite bool                  // 超级用户邀请机器人入群时自动同意
+	InviteAction          RequestAction         // 其他人邀请机器人入群时的处理方式，默认不处理
+	PendingTimeout        time.Duration         // 待审核请求超时时间，为 0 时使用 DefaultPendingTimeout
+	TimeoutAction         RequestAction         // 待审核请求超时后的处理方式，默认拒绝
+}
+
+// Validate 检查并编译规则中的正则表达式
+func (p *RequestPolicyConfig) Validate() error {
+	_, err := p.compileAnswers()
+	return err
+}
+
+// compileAnswers 编译所有规则的验证信息正则表达式，按表达式去重
+func (p *RequestPolicyConfig) compileAnswers() (map[string]*regexp.Regexp, error) {
+	rules := map[string]RequestRule{"friend": p.Friend, "group": p.Group}
+	for groupID, rule := range p.Groups {
+		rules[fmt.Sprintf("groups.%d", groupID)] = rule
+	}
+
+	answers := make(map[string]*regexp.Regexp)
+	for name, rule := range rules {
+		if rule.Answer == "" || answers[rule.Answer] != nil {
+			continue
+		}
+		re, err := regexp.Compile(rule.Answer)
+		if err != nil {
+			return nil, fmt.Errorf("请求规则 %s 的 answer 无效: %w", name, err)
+		}
+		answers[rule.Answer] = re
+	}
+	return answers, nil
+}
+
+// PendingRequest 等待审核的请求
+type PendingRequest struct {
+	ID       int64       // 审核编号
+	Event    event.Event // *event.FriendRequestEvent 或 *event.GroupRequestEvent
+	GroupID  int64       // 申请加入的群，加好友请求为 0
+	UserID   int64       // 申请人
+	Comment  string      // 验证信息
+	Time     time.Time   // 收到请求的时间
+	Deadline time.Time   // 超时时间
+
+	rule      RequestRule
+	noticeIDs []int64
+	timer     *time.Timer
+}
+
+// RequestManager 按策略自动处理加好友、加群请求
+// 无法自动处理的请求会通知审核人，审核人引用通知消息回复 "同意" 或 "拒绝 理由" 即可处理；
+// 待审核请求保存在机器人的存储中，重启后恢复并继续计算超时
+type RequestManager struct {
+	bot     *Bot
+	policy  *RequestPolicyConfig
+	answers map[string]*regexp.Regexp // 验证信息正则表达式 -> 编译结果
+
+	mu       sync.Mutex
+	nextID   int64
+	pending  map[int64]*PendingRequest
+	byNotice map[int64]int64 // 通知消息 ID -> 审核编号
+}
+
+// NewRequestManager 创建请求管理器，规则中的正则表达式无效时返回错误
+// 机器人设置了存储时从存储恢复待审核请求
+func NewRequestManager(bot *Bot, policy *RequestPolicyConfig) (*RequestManager, error) {
+	answers, err := policy.compileAnswers()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &RequestManager{
+		bot:      bot,
+		policy:   policy,
+		answers:  answers,
+		pending:  make(map[int64]*PendingRequest),
+		byNotice: make(map[int64]int64),
+	}
+	m.restore()
+	return m, nil
+}
+
+// HandleEvent 处理请求事件和审核人的回复
+func (m *RequestManager) HandleEvent(evt event.Event) {
+	switch evt.(type) {
+	case *event.FriendRequestEvent, *event.GroupRequestEvent:
+		go m.handleRequest(evt)
+	case *event.PrivateMessageEvent, *event.GroupMessageEvent:
+		// 处理审核会调用 API 并回复审核人，回复可能在发送队列或等待重连时阻塞，不能占用事件分发
+		go m.handleReview(NewContext(evt, m.bot))
+	}
+}
+
+// handleRequest 按策略处理请求
+func (m *RequestManager) handleRequest(evt event.Event) {
+	var groupID, userID int64
+	var comment string
+	var rule RequestRule
+
+	switch e := evt.(type) {
+	case *event.FriendRequestEvent:
+		userID, comment, rule = e.UserID, e.Comment, m.policy.Friend
+	case *event.GroupRequestEvent:
+		groupID, userID, comment = e.GroupID, e.UserID, e.Comment
+		if e.SubType == types.GroupRequestSubTypeInvite {
+			m.handleInvite(e)
+			return
+		}
+		rule = m.policy.Group
+		if r, ok := m.policy.Groups[groupID]; ok {
+			rule = r
+		}
+	default:
+		return
+	}
+
+	if m.IsBlocked(groupID, userID) {
+		logger.Info("拒绝黑名单用户的请求", "groupID", groupID, "userID", userID)
+		m.resolve(evt, false, rule.RejectReason)
+		return
+	}
+
+	if m.matchRule(rule, userID, comment) {
+		m.resolve(evt, true, "")
+		return
+	}
+
+	switch rule.Fallback {
+	case RequestActionApprove:
+		m.resolve(evt, true, "")
+	case RequestActionReject:
+		m.resolve(evt, false, rule.RejectReason)
+	case RequestActionIgnore:
+	default:
+		m.addPending(evt, groupID, userID, comment, rule)
+	}
+}
+
+// handleInvite 处理邀请机器人入群的请求
+func (m *RequestManager) handleInvite(evt *event.GroupRequestEvent) {
+	if m.policy.AcceptSuperUserInvite && m.isSuperUser(evt.UserID) {
+		m.resolve(evt, true, "")
+		return
+	}
+
+	switch m.policy.InviteAction {
+	case RequestActionApprove:
+		m.resolve(evt, true, "")
+	case RequestActionReject:
+		m.resolve(evt, false, "")
+	case RequestActionManual:
+		m.addPending(evt, evt.GroupID, evt.UserID, evt.Comment, RequestRule{})
+	}
+}
+
+// matchRule 检查请求是否满足自动同意的条件
+func (m *RequestManager) matchRule(rule RequestRule, userID int64, comment string) bool {
+	if rule.Answer == "" && rule.MinLevel == 0 {
+		return false
+	}
+
+	if rule.Answer != "" {
+		re := m.answers[rule.Answer]
+		if re == nil || !re.MatchString(comment) {
+			return false
+		}
+	}
+
+	if rule.MinLevel > 0 {
+		if m.bot.API == nil {
+			return false
+		}
+		resp, err := m.bot.API.GetStrangerInfo(userID, false)
+		if err != nil {
+			logger.Warn("获取申请人信息失败", "userID", userID, "error", err)
+			return false
+		}
+		if int(resp.Data.Level) < rule.MinLevel {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolve 调用 API 同意或拒绝请求
+func (m *RequestManager) resolve(evt event.Event, approve bool, reason string) error {
+	if m.bot.API == nil {
+		return fmt.Errorf("API 客户端未初始化")
+	}
+
+	var err error
+	switch e := evt.(type) {
+	case *event.FriendRequestEvent:
+		err = m.bot.API.SetFriendAddRequest(e.Flag, approve, "")
+	case *event.GroupRequestEvent:
+		err = m.bot.API.SetGroupAddRequest(e.Flag, string(e.SubType), approve, reason)
+	default:
+		return fmt.Errorf("不是请求事件")
+	}
+	if err != nil {
+		logger.Warn("处理请求失败", "approve", approve, "error", err)
+	}
+	return err
+}
+
+// ========== 人工审核 ==========
+
+// addPending 加入待审核列表并通知审核人
+func (m *RequestManager) addPending(evt event.Event, groupID, userID int64, comment string, rule RequestRule) {
+	timeout := m.policy.PendingTimeout
+	if timeout <= 0 {
+		timeout = DefaultPendingTimeout
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	m.nextID++
+	req := &PendingRequest{
+		ID:       m.nextID,
+		Event:    evt,
+		GroupID:  groupID,
+		UserID:   userID,
+		Comment:  comment,
+		Time:     now,
+		Deadline: now.Add(timeout),
+		rule:     rule,
+	}
+	m.pending[req.ID] = req
+	req.timer = time.AfterFunc(timeout, func() { m.expire(req.ID) })
+	m.mu.Unlock()
+
+	logger.Info("请求等待审核", "id", req.ID, "groupID", groupID, "userID", userID)
+
+	noticeIDs := m.notifyReviewers(req)
+	m.mu.Lock()
+	if _, ok := m.pending[req.ID]; ok {
+		req.noticeIDs = noticeIDs
+		for _, id := range noticeIDs {
+			m.byNotice[id] = req.ID
+		}
+		m.save(req)
+	}
+	m.mu.Unlock()
+}
+
+// notifyReviewers 发送审核通知，返回通知消息 ID
+func (m *RequestManager) notifyReviewers(req *PendingRequest) []int64 {
+	if m.bot.API == nil {
+		return nil
+	}
+
+	var text string
+	switch e := req.Event.(type) {
+	case *event.GroupRequestEvent:
+		if e.SubType == types.GroupRequestSubTypeInvite {
+			text = fmt.Sprintf("[入群邀请 #%d] 用户 %d 邀请机器人加入群 %d", req.ID, req.UserID, req.GroupID)
+		} else {
+			text = fmt.Sprintf("[加群申请 #%d] 用户 %d 申请加入群 %d", req.ID, req.UserID, req.GroupID)
+		}
+	default:
+		text = fmt.Sprintf("[好友申请 #%d] 用户 %d 申请添加好友", req.ID, req.UserID)
+	}
+	if req.Comment != "" {
+		text += "\n验证信息：" + req.Comment
+	}
+	text += "\n引用本消息回复「同意」或「拒绝 理由」进行处理"
+
+	var ids []int64
+	if req.rule.NotifyGroup != 0 {
+		resp, err := m.bot.API.SendGroupMsg(req.rule.NotifyGroup, text)
+		if err != nil {
+			logger.Warn("发送审核通知失败", "groupID", req.rule.NotifyGroup, "error", err)
+			return nil
+		}
+		return append(ids, resp.Data.MessageID)
+	}
+
+	for _, admin := range m.reviewers(req.rule) {
+		resp, err := m.bot.API.SendPrivateMsg(admin, text)
+		if err != nil {
+			logger.Warn("发送审核通知失败", "userID", admin, "error", err)
+			continue
+		}
+		ids = append(ids, resp.Data.MessageID)
+	}
+	return ids
+}
+
+// handleReview 处理审核人引用通知消息的回复
+func (m *RequestManager) handleReview(ctx *Context) {
+	replyID := ctx.GetReplyID()
+	if replyID == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	id, ok := m.byNotice[replyID]
+	req := m.pending[id]
+	m.mu.Unlock()
+	if !ok || req == nil {
+		return
+	}
+
+	approve, reason, ok := parseReviewReply(ctx.GetPlainText())
+	if !ok {
+		return
+	}
+
+	if !m.isReviewer(req.rule, ctx.GetUserID()) {
+		return
+	}
+
+	if err := m.Resolve(id, approve, reason); err != nil {
+		ctx.Reply(fmt.Sprintf("处理 #%d 失败：%v", id, err))
+		return
+	}
+	if approve {
+		ctx.Reply(fmt.Sprintf("已同意 #%d", id))
+	} else {
+		ctx.Reply(fmt.Sprintf("已拒绝 #%d", id))
+	}
+}
+
+// parseReviewReply 解析审核人的回复，第一个词必须是 "同意" 或 "拒绝"，其后以空白分隔的内容作为理由
+// "同意吗？" 这类只是以关键词开头的回复不视为审核
+func parseReviewReply(text string) (approve bool, reason string, ok bool) {
+	text = strings.TrimSpace(text)
+	word, reason := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		word, reason = text[:i], strings.TrimSpace(text[i:])
+	}
+
+	switch word {
+	case "同意":
+		return true, reason, true
+	case "拒绝":
+		return false, reason, true
+	default:
+		return false, "", false
+	}
+}
+
+// expire 按超时策略处理过期的请求
+func (m *RequestManager) expire(id int64) {
+	req := m.take(id)
+	if req == nil {
+		return
+	}
+
+	logger.Info("待审核请求已超时", "id", id, "action", m.policy.TimeoutAction)
+	switch m.policy.TimeoutAction {
+	case RequestActionApprove:
+		m.resolve(req.Event, true, "")
+	case RequestActionIgnore:
+	default:
+		m.resolve(req.Event, false, req.rule.RejectReason)
+	}
+}
+
+// take 从待审核列表中移除请求
+func (m *RequestManager) take(id int64) *PendingRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, ok := m.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(m.pending, id)
+	for _, noticeID := range req.noticeIDs {
+		delete(m.byNotice, noticeID)
+	}
+	if req.timer != nil {
+		req.timer.Stop()
+	}
+	if m.bot.Storage != nil {
+		if err := m.bot.Storage.Delete(m.pendingKey(id)); err != nil {
+			logger.Warn("删除待审核请求失败", "id", id, "error", err)
+		}
+	}
+	return req
+}
+
+// Pending 获取等待审核的请求，按编号排列
+func (m *RequestManager) Pending() []*PendingRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]*PendingRequest, 0, len(m.pending))
+	for _, req := range m.pending {
+		list = append(list, req)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Resolve 处理待审核的请求，reason 为拒绝理由
+func (m *RequestManager) Resolve(id int64, approve bool, reason string) error {
+	req := m.take(id)
+	if req == nil {
+		return fmt.Errorf("请求 #%d 不存在或已处理", id)
+	}
+	if !approve && reason == "" {
+		reason = req.rule.RejectReason
+	}
+	return m.resolve(req.Event, approve, reason)
+}
+
+// reviewers 获取规则的审核人
+func (m *RequestManager) reviewers(rule RequestRule) []int64 {
+	if len(rule.Admins) > 0 {
+		return rule.Admins
+	}
+	return m.bot.Config.SuperUsers
+}
+
+// isReviewer 是否为规则的审核人，超级用户总是可以审核
+func (m *RequestManager) isReviewer(rule RequestRule, userID int64) bool {
+	for _, admin := range rule.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return m.isSuperUser(userID)
+}
+
+// isSuperUser 是否为超级用户
+func (m *RequestManager) isSuperUser(userID int64) bool {
+	for _, su := range m.bot.Config.SuperUsers {
+		if su == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== 待审核请求持久化 ==========
+
+// storedPendingRequest 保存在存储中的待审核请求
+type storedPendingRequest struct {
+	ID        int64                     `json:"id"`
+	Friend    *event.FriendRequestEvent `json:"friend,omitempty"`
+	Group     *event.GroupRequestEvent  `json:"group,omitempty"`
+	Time      time.Time                 `json:"time"`
+	Deadline  time.Time                 `json:"deadline"`
+	Rule      RequestRule               `json:"rule"`
+	NoticeIDs []int64                   `json:"notice_ids,omitempty"`
+}
+
+// pendingKeyPrefix 待审核请求存储键前缀，请求的 flag 只对收到请求的账号有效，因此按账号区分
+func (m *RequestManager) pendingKeyPrefix() string {
+	return fmt.Sprintf("request_pending:%d:", m.bot.SelfID)
+}
+
+// pendingKey 待审核请求存储键
+func (m *RequestManager) pendingKey(id int64) string {
+	return fmt.Sprintf("%s%d", m.pendingKeyPrefix(), id)
+}
+
+// save 保存待审核请求，调用方需持有锁
+func (m *RequestManager) save(req *PendingRequest) {
+	if m.bot.Storage == nil {
+		return
+	}
+
+	stored := storedPendingRequest{
+		ID:        req.ID,
+		Time:      req.Time,
+		Deadline:  req.Deadline,
+		Rule:      req.rule,
+		NoticeIDs: req.noticeIDs,
+	}
+	switch e := req.Event.(type) {
+	case *event.FriendRequestEvent:
+		stored.Friend = e
+	case *event.GroupRequestEvent:
+		stored.Group = e
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		logger.Warn("序列化待审核请求失败", "id", req.ID, "error", err)
+		return
+	}
+	if err := m.bot.Storage.Set(m.pendingKey(req.ID), data); err != nil {
+		logger.Warn("保存待审核请求失败", "id", req.ID, "error", err)
+	}
+}
+
+// restore 从存储恢复待审核请求并重新设置超时，已超时的请求立即按超时策略处理
+func (m *RequestManager) restore() {
+	if m.bot.Storage == nil {
+		return
+	}
+	keys, err := m.bot.Storage.Keys(m.pendingKeyPrefix())
+	if err != nil {
+		logger.Warn("读取待审核请求失败", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		data, err := m.bot.Storage.Get(key)
+		if err != nil || data == nil {
+			continue
+		}
+		var stored storedPendingRequest
+		if err := json.Unmarshal(data, &stored); err != nil {
+			logger.Warn("解析待审核请求失败", "key", key, "error", err)
+			continue
+		}
+
+		req := &PendingRequest{
+			ID:        stored.ID,
+			Time:      stored.Time,
+			Deadline:  stored.Deadline,
+			rule:      stored.Rule,
+			noticeIDs: stored.NoticeIDs,
+		}
+		switch {
+		case stored.Friend != nil:
+			req.Event, req.UserID, req.Comment = stored.Friend, stored.Friend.UserID, stored.Friend.Comment
+		case stored.Group != nil:
+			req.Event, req.GroupID, req.UserID, req.Comment = stored.Group, stored.Group.GroupID, stored.Group.UserID, stored.Group.Comment
+		default:
+			continue
+		}
+
+		m.pending[req.ID] = req
+		for _, noticeID := range req.noticeIDs {
+			m.byNotice[noticeID] = req.ID
+		}
+		m.nextID = max(m.nextID, req.ID)
+		req.timer = time.AfterFunc(max(time.Until(req.Deadline), 0), func() { m.expire(req.ID) })
+	}
+	if len(m.pending) > 0 {
+		logger.Info("已恢复待审核请求", "count", len(m.pending))
+	}
+}
+
+// ========== 黑名单 ==========
+
+// blacklistKey 黑名单存储键，groupID 为 0 表示全局黑名单
+func blacklistKey(groupID, userID int64) string {
+	return fmt.Sprintf("request_blacklist:%d:%d", groupID, userID)
+}
+
+// Block 将用户加入黑名单，groupID 为 0 时拒绝该用户的所有请求
+func (m *RequestManager) Block(groupID, userID int64) error {
+	if m.bot.Storage == nil {
+		return fmt.Errorf("存储未初始化")
+	}
+	return m.bot.Storage.Set(blacklistKey(groupID, userID), []byte("1"))
+}
+
+// Unblock 将用户移出黑名单
+func (m *RequestManager) Unblock(groupID, userID int64) error {
+	if m.bot.Storage == nil {
+		return fmt.Errorf("存储未初始化")
+	}
+	return m.bot.Storage.Delete(blacklistKey(groupID, userID))
+}
+
+// IsBlocked 用户是否在全局黑名单或指定群的黑名单中
+func (m *RequestManager) IsBlocked(groupID, userID int64) bool {
+	if m.bot.Storage == nil {
+		return false
+	}
+	if m.bot.Storage.Has(blacklistKey(0, userID)) {
+		return true
+	}
+	return groupID != 0 && m.bot.Storage.Has(blacklistKey(groupID, userID))
+}
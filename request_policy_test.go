@@ -0,0 +1,179 @@
+package xbot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/api"
+	"github.com/xiaoyi510/xbot/driver"
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/message"
+	"github.com/xiaoyi510/xbot/storage"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// recordDriver 记录 API 调用的驱动器，发送消息时返回递增的消息 ID
+type recordDriver struct {
+	mu     sync.Mutex
+	calls  []map[string]interface{}
+	nextID int64
+}
+
+func (d *recordDriver) Connect() error                              { return nil }
+func (d *recordDriver) SetEventHandler(handler driver.EventHandler) {}
+func (d *recordDriver) Close() error                                { return nil }
+func (d *recordDriver) IsConnected() bool                           { return true }
+
+func (d *recordDriver) CallAPI(action string, params map[string]interface{}) (*types.APIResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	params["action"] = action
+	d.calls = append(d.calls, params)
+	d.nextID++
+	return &types.APIResponse{Status: "ok", Data: map[string]interface{}{"message_id": float64(d.nextID)}}, nil
+}
+
+// lastCall 获取最后一次指定 API 的调用参数
+func (d *recordDriver) lastCall(action string) map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := len(d.calls) - 1; i >= 0; i-- {
+		if d.calls[i]["action"] == action {
+			return d.calls[i]
+		}
+	}
+	return nil
+}
+
+// TestRequestPolicy 测试按规则自动处理请求以及审核人引用回复处理待审核请求
+func TestRequestPolicy(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{
+		SelfID:  10000,
+		Config:  &Config{SuperUsers: []int64{1}},
+		API:     api.NewClient(d),
+		Storage: storage.NewMemoryStorage(),
+	}
+	m, err := NewRequestManager(bot, &RequestPolicyConfig{
+		Group:                 RequestRule{Answer: `答案：42$`, RejectReason: "回答错误"},
+		AcceptSuperUserInvite: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRequestManager failed: %v", err)
+	}
+
+	base := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeRequest}
+
+	// 答案正确自动同意
+	m.handleRequest(&event.GroupRequestEvent{BaseEvent: base, SubType: types.GroupRequestSubTypeAdd, GroupID: 100, UserID: 200, Comment: "问题：?\n答案：42", Flag: "a"})
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call == nil || call["flag"] != "a" || call["approve"] != true {
+		t.Fatalf("Expected request a to be approved, got %v", call)
+	}
+
+	// 黑名单用户直接拒绝
+	m.Block(0, 201)
+	m.handleRequest(&event.GroupRequestEvent{BaseEvent: base, SubType: types.GroupRequestSubTypeAdd, GroupID: 100, UserID: 201, Comment: "答案：42", Flag: "b"})
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call["flag"] != "b" || call["approve"] != false || call["reason"] != "回答错误" {
+		t.Fatalf("Expected request b to be rejected, got %v", call)
+	}
+
+	// 超级用户邀请自动同意
+	m.handleRequest(&event.GroupRequestEvent{BaseEvent: base, SubType: types.GroupRequestSubTypeInvite, GroupID: 101, UserID: 1, Flag: "c"})
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call["flag"] != "c" || call["approve"] != true {
+		t.Fatalf("Expected invite c to be approved, got %v", call)
+	}
+
+	// 答案错误转交审核人
+	m.handleRequest(&event.GroupRequestEvent{BaseEvent: base, SubType: types.GroupRequestSubTypeAdd, GroupID: 100, UserID: 202, Comment: "答案：0", Flag: "d"})
+	pending := m.Pending()
+	if len(pending) != 1 || pending[0].UserID != 202 || len(pending[0].noticeIDs) != 1 {
+		t.Fatalf("Expected 1 pending request with a notice, got %+v", pending)
+	}
+
+	// 非审核人的回复不处理
+	reply := func(userID int64, text string) {
+		m.handleReview(NewContext(&event.PrivateMessageEvent{
+			BaseEvent: event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+			UserID:    userID,
+			ParsedMessage: message.Message{
+				message.Reply(pending[0].noticeIDs[0]),
+				message.Text(text),
+			},
+		}, bot))
+	}
+	reply(2, "同意")
+	if len(m.Pending()) != 1 {
+		t.Fatal("Expected non-reviewer reply to be ignored")
+	}
+
+	// 只以关键词开头的回复不视为审核
+	reply(1, "同意吗？")
+	if len(m.Pending()) != 1 {
+		t.Fatal("Expected reply not starting with a whole review word to be ignored")
+	}
+
+	reply(1, "拒绝 请重新申请")
+	if len(m.Pending()) != 0 {
+		t.Fatal("Expected request to be resolved")
+	}
+	if call := d.lastCall(api.ActionSetGroupAddRequest); call["flag"] != "d" || call["approve"] != false || call["reason"] != "请重新申请" {
+		t.Fatalf("Expected request d to be rejected with reason, got %v", call)
+	}
+}
+
+// TestRequestPendingRestore 测试待审核请求在重启后恢复，恢复后可以审核并继续计算超时
+func TestRequestPendingRestore(t *testing.T) {
+	d := &recordDriver{}
+	bot := &Bot{
+		SelfID:  10000,
+		Config:  &Config{SuperUsers: []int64{1}},
+		API:     api.NewClient(d),
+		Storage: storage.NewMemoryStorage(),
+	}
+	policy := &RequestPolicyConfig{Friend: RequestRule{Fallback: RequestActionManual}}
+
+	if _, err := NewRequestManager(bot, &RequestPolicyConfig{Group: RequestRule{Answer: "("}}); err == nil {
+		t.Error("Expected invalid answer pattern to be rejected")
+	}
+
+	m, _ := NewRequestManager(bot, policy)
+	base := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeRequest}
+	m.handleRequest(&event.FriendRequestEvent{BaseEvent: base, UserID: 300, Comment: "你好", Flag: "e"})
+	m.handleRequest(&event.FriendRequestEvent{BaseEvent: base, UserID: 301, Flag: "f"})
+
+	// 模拟重启：新的管理器从存储恢复
+	restored, _ := NewRequestManager(bot, policy)
+	pending := restored.Pending()
+	if len(pending) != 2 || pending[0].UserID != 300 || pending[0].Comment != "你好" || len(pending[0].noticeIDs) != 1 {
+		t.Fatalf("Expected 2 restored pending requests, got %+v", pending)
+	}
+
+	restored.handleReview(NewContext(&event.PrivateMessageEvent{
+		BaseEvent:     event.BaseEvent{SelfID: 10000, PostType: types.PostTypeMessage},
+		UserID:        1,
+		ParsedMessage: message.Message{message.Reply(pending[0].noticeIDs[0]), message.Text("同意")},
+	}, bot))
+	if call := d.lastCall(api.ActionSetFriendAddRequest); call == nil || call["flag"] != "e" || call["approve"] != true {
+		t.Fatalf("Expected restored request e to be approved, got %v", call)
+	}
+
+	// 已超时的请求恢复后立即按超时策略拒绝
+	req := restored.Pending()[0]
+	restored.mu.Lock()
+	req.Deadline = time.Now().Add(-time.Second)
+	restored.save(req)
+	restored.mu.Unlock()
+
+	expired, _ := NewRequestManager(bot, policy)
+	deadline := time.Now().Add(time.Second)
+	for len(expired.Pending()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if call := d.lastCall(api.ActionSetFriendAddRequest); call["flag"] != "f" || call["approve"] != false {
+		t.Fatalf("Expected expired request f to be rejected, got %v", call)
+	}
+	if keys, _ := bot.Storage.Keys("request_pending:"); len(keys) != 0 {
+		t.Errorf("Expected resolved requests to be removed from storage, got %v", keys)
+	}
+}
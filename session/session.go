@@ -3,10 +3,14 @@ package session
 import (
 	"errors"
 	"github.com/xiaoyi510/xbot/utils"
+	"io"
 	"sync"
 	"time"
 )
 
+// ErrRangeUnsupported 会话存储未实现 Ranger 接口，无法遍历会话
+var ErrRangeUnsupported = errors.New("会话存储不支持遍历")
+
 // Session 会话
 type Session struct {
 	ID        string
@@ -56,12 +60,42 @@ func (m *Manager) Set(session *Session) error {
 	return m.store.Set(key, session, m.ttl)
 }
 
+// SetWithTTL 设置会话并指定有效期，ttl 为 0 时使用默认有效期
+func (m *Manager) SetWithTTL(session *Session, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+	session.UpdatedAt = time.Now()
+	key := utils.GenerateSessionKey(session.UserID, session.GroupID)
+	return m.store.Set(key, session, ttl)
+}
+
 // Delete 删除会话
 func (m *Manager) Delete(userID, groupID int64) error {
 	key := utils.GenerateSessionKey(userID, groupID)
 	return m.store.Delete(key)
 }
 
+// Range 遍历所有未过期的会话，fn 返回 false 时停止
+// 存储未实现 Ranger 接口时返回 ErrRangeUnsupported
+func (m *Manager) Range(fn func(session *Session) bool) error {
+	ranger, ok := m.store.(Ranger)
+	if !ok {
+		return ErrRangeUnsupported
+	}
+	return ranger.Range(func(key string, session *Session) bool {
+		return fn(session)
+	})
+}
+
+// Close 关闭会话存储，存储实现 io.Closer 时调用其 Close 停止清理协程
+func (m *Manager) Close() error {
+	if closer, ok := m.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 // CreateWaitSession 创建等待会话
 func (m *Manager) CreateWaitSession(userID, groupID int64, timeout time.Duration) *Session {
 	key := utils.GenerateSessionKey(userID, groupID)
@@ -1,8 +1,12 @@
 package session
 
 import (
+	"encoding/json"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/xiaoyi510/xbot/storage"
 )
 
 // Store 会话存储接口
@@ -12,9 +16,16 @@ type Store interface {
 	Delete(key string) error
 }
 
+// Ranger 可遍历会话的存储，存储实现该接口时 Manager.Range 可用
+type Ranger interface {
+	Range(fn func(key string, session *Session) bool) error // 遍历未过期的会话，fn 返回 false 时停止
+}
+
 // MemoryStore 内存存储实现
 type MemoryStore struct {
-	data sync.Map
+	data      sync.Map
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 type memoryItem struct {
@@ -24,7 +35,7 @@ type memoryItem struct {
 
 // NewMemoryStore 创建内存存储
 func NewMemoryStore() *MemoryStore {
-	store := &MemoryStore{}
+	store := &MemoryStore{stop: make(chan struct{})}
 
 	// 启动清理协程
 	go store.cleanup()
@@ -67,12 +78,37 @@ func (s *MemoryStore) Delete(key string) error {
 	return nil
 }
 
+// Range 遍历未过期的会话
+func (s *MemoryStore) Range(fn func(key string, session *Session) bool) error {
+	now := time.Now()
+	s.data.Range(func(key, value interface{}) bool {
+		item := value.(*memoryItem)
+		if now.After(item.expiresAt) {
+			return true
+		}
+		return fn(key.(string), item.session)
+	})
+	return nil
+}
+
+// Close 停止清理协程
+func (s *MemoryStore) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
 // cleanup 清理过期会话
 func (s *MemoryStore) cleanup() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
 		now := time.Now()
 		s.data.Range(func(key, value interface{}) bool {
 			item := value.(*memoryItem)
@@ -83,3 +119,113 @@ func (s *MemoryStore) cleanup() {
 		})
 	}
 }
+
+// StorageStore 基于 storage.Storage 的会话存储，会话以 JSON 保存，使用持久化存储时重启后可恢复
+// Data 中的值经过 JSON 编解码，数字会变为 float64，复杂结构建议自行序列化为字符串保存
+type StorageStore struct {
+	store     storage.Storage
+	prefix    string
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type storedSession struct {
+	Session   *Session
+	ExpiresAt time.Time
+}
+
+// NewStorageStore 创建基于 storage.Storage 的会话存储，prefix 为存储键前缀
+// 与 MemoryStore 一样启动清理协程，定期删除过期的会话
+func NewStorageStore(store storage.Storage, prefix string) *StorageStore {
+	s := &StorageStore{store: store, prefix: prefix, stop: make(chan struct{})}
+
+	// 启动清理协程
+	go s.cleanup()
+
+	return s
+}
+
+// Get 获取会话，会话不存在或已过期时返回 nil
+func (s *StorageStore) Get(key string) (*Session, error) {
+	item, err := s.load(s.prefix + key)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return item.Session, nil
+}
+
+// load 读取存储键对应的会话，不存在或已过期时返回 nil，过期的会话同时被删除
+func (s *StorageStore) load(storeKey string) (*storedSession, error) {
+	data, err := s.store.Get(storeKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var item storedSession
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	if time.Now().After(item.ExpiresAt) {
+		s.store.Delete(storeKey)
+		return nil, nil
+	}
+	return &item, nil
+}
+
+// Set 设置会话
+func (s *StorageStore) Set(key string, session *Session, ttl time.Duration) error {
+	data, err := json.Marshal(storedSession{Session: session, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+	return s.store.Set(s.prefix+key, data)
+}
+
+// Delete 删除会话
+func (s *StorageStore) Delete(key string) error {
+	return s.store.Delete(s.prefix + key)
+}
+
+// Range 遍历未过期的会话，遍历时删除已过期的会话
+func (s *StorageStore) Range(fn func(key string, session *Session) bool) error {
+	keys, err := s.store.Keys(s.prefix)
+	if err != nil {
+		return err
+	}
+	for _, storeKey := range keys {
+		item, err := s.load(storeKey)
+		if err != nil || item == nil {
+			continue
+		}
+		if !fn(strings.TrimPrefix(storeKey, s.prefix), item.Session) {
+			break
+		}
+	}
+	return nil
+}
+
+// RemoveExpired 删除所有过期的会话
+func (s *StorageStore) RemoveExpired() error {
+	return s.Range(func(key string, session *Session) bool { return true })
+}
+
+// Close 停止清理协程，底层存储由调用方关闭
+func (s *StorageStore) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// cleanup 定期清理过期会话
+func (s *StorageStore) cleanup() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.RemoveExpired()
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaoyi510/xbot/storage"
+)
+
+// TestStorageStoreExpire 测试过期会话在遍历和清理时被删除
+func TestStorageStoreExpire(t *testing.T) {
+	backend := storage.NewMemoryStorage()
+	store := NewStorageStore(backend, "session:")
+
+	store.Set("user:1", &Session{ID: "user:1", UserID: 1}, time.Hour)
+	store.Set("user:2", &Session{ID: "user:2", UserID: 2}, -time.Second)
+
+	var keys []string
+	store.Range(func(key string, session *Session) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 1 || keys[0] != "user:1" {
+		t.Errorf("Expected only unexpired session, got %v", keys)
+	}
+
+	store.Set("user:3", &Session{ID: "user:3", UserID: 3}, -time.Second)
+	if err := store.RemoveExpired(); err != nil {
+		t.Fatalf("RemoveExpired failed: %v", err)
+	}
+	if remaining, _ := backend.Keys("session:"); len(remaining) != 1 {
+		t.Errorf("Expected expired sessions to be deleted, got %v", remaining)
+	}
+}
+
+// mapStore 只实现 Store 接口的存储
+type mapStore map[string]*Session
+
+func (s mapStore) Get(key string) (*Session, error) { return s[key], nil }
+func (s mapStore) Set(key string, session *Session, ttl time.Duration) error {
+	s[key] = session
+	return nil
+}
+func (s mapStore) Delete(key string) error { delete(s, key); return nil }
+
+// TestManagerOptionalStore 测试存储未实现 Ranger 和 io.Closer 时 Manager 仍可使用
+func TestManagerOptionalStore(t *testing.T) {
+	m := NewManager(mapStore{}, time.Hour)
+	m.Set(&Session{UserID: 1})
+	if _, ok := m.Get(1, 0); !ok {
+		t.Fatal("Expected session to be stored")
+	}
+	if err := m.Range(func(*Session) bool { return true }); err != ErrRangeUnsupported {
+		t.Errorf("Expected ErrRangeUnsupported, got %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Unexpected close error: %v", err)
+	}
+
+	memory := NewManager(NewMemoryStore(), time.Hour)
+	memory.Set(&Session{UserID: 1})
+	count := 0
+	memory.Range(func(*Session) bool { count++; return true })
+	if count != 1 {
+		t.Errorf("Expected memory store to be ranged, got %d sessions", count)
+	}
+	memory.Close()
+	memory.Close()
+}
@@ -0,0 +1,51 @@
+package storage
+
+import "strings"
+
+// Prefixed 为底层存储的所有键添加前缀，用于在共享存储中隔离插件数据
+type Prefixed struct {
+	store  Storage
+	prefix string
+}
+
+// NewPrefixed 创建带前缀的存储
+func NewPrefixed(store Storage, prefix string) *Prefixed {
+	return &Prefixed{store: store, prefix: prefix}
+}
+
+// Get 获取值
+func (p *Prefixed) Get(key string) ([]byte, error) {
+	return p.store.Get(p.prefix + key)
+}
+
+// Set 设置值
+func (p *Prefixed) Set(key string, value []byte) error {
+	return p.store.Set(p.prefix+key, value)
+}
+
+// Delete 删除值
+func (p *Prefixed) Delete(key string) error {
+	return p.store.Delete(p.prefix + key)
+}
+
+// Has 是否存在
+func (p *Prefixed) Has(key string) bool {
+	return p.store.Has(p.prefix + key)
+}
+
+// Keys 获取指定前缀的键，返回的键不包含存储前缀
+func (p *Prefixed) Keys(prefix string) ([]string, error) {
+	keys, err := p.store.Keys(p.prefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys, nil
+}
+
+// Close 不关闭底层存储，底层存储由创建者负责关闭
+func (p *Prefixed) Close() error {
+	return nil
+}
@@ -0,0 +1,136 @@
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/xiaoyi510/xbot/utils"
+)
+
+// Exporter 跨度导出器
+type Exporter interface {
+	ExportSpan(span *SpanData)
+}
+
+// ========== 标准输出导出器 ==========
+
+// WriterExporter 以 JSON 行输出跨度的导出器
+// 字段命名参考 OpenTelemetry OTLP/JSON，便于离线排查或导入其它工具
+type WriterExporter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewStdoutExporter 创建输出到标准输出的导出器
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewWriterExporter 创建输出到指定 io.Writer 的导出器
+func NewWriterExporter(out io.Writer) *WriterExporter {
+	return &WriterExporter{out: out}
+}
+
+// spanJSON 跨度的 JSON 表示
+type spanJSON struct {
+	TraceID           string                 `json:"traceId"`
+	SpanID            string                 `json:"spanId"`
+	ParentSpanID      string                 `json:"parentSpanId,omitempty"`
+	Name              string                 `json:"name"`
+	StartTimeUnixNano int64                  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64                  `json:"endTimeUnixNano"`
+	DurationMs        float64                `json:"durationMs"`
+	Attributes        map[string]interface{} `json:"attributes,omitempty"`
+	Status            struct {
+		Code    StatusCode `json:"code"`
+		Message string     `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+// MarshalJSON 以 OTLP 风格序列化跨度
+func (d *SpanData) MarshalJSON() ([]byte, error) {
+	out := spanJSON{
+		TraceID:           d.TraceID.String(),
+		SpanID:            d.SpanID.String(),
+		Name:              d.Name,
+		StartTimeUnixNano: d.StartTime.UnixNano(),
+		EndTimeUnixNano:   d.EndTime.UnixNano(),
+		DurationMs:        float64(d.Duration().Microseconds()) / 1000,
+		Attributes:        d.Attributes,
+	}
+	if d.ParentSpanID.IsValid() {
+		out.ParentSpanID = d.ParentSpanID.String()
+	}
+	out.Status.Code = d.StatusCode
+	out.Status.Message = d.StatusMessage
+
+	return json.Marshal(out)
+}
+
+// ExportSpan 导出跨度
+func (e *WriterExporter) ExportSpan(span *SpanData) {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.out.Write(append(data, '\n'))
+}
+
+// ========== 内存导出器 ==========
+
+// MemoryExporter 在内存中保留最近跨度的导出器
+// 适用于离线调试和管理后台查询
+type MemoryExporter struct {
+	spans *utils.RingBuffer[*SpanData]
+}
+
+// NewMemoryExporter 创建内存导出器，size 为保留的最大跨度数量
+func NewMemoryExporter(size int) *MemoryExporter {
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemoryExporter{
+		spans: utils.NewRingBuffer[*SpanData](size),
+	}
+}
+
+// ExportSpan 导出跨度
+func (e *MemoryExporter) ExportSpan(span *SpanData) {
+	e.spans.Push(span)
+}
+
+// Spans 获取保留的所有跨度，按结束时间从旧到新排列
+func (e *MemoryExporter) Spans() []*SpanData {
+	return e.spans.Items()
+}
+
+// Trace 获取指定链路的所有跨度
+func (e *MemoryExporter) Trace(traceID string) []*SpanData {
+	var result []*SpanData
+	for _, span := range e.Spans() {
+		if span.TraceID.String() == traceID {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+// Reset 清空已保留的跨度
+func (e *MemoryExporter) Reset() {
+	e.spans.Clear()
+}
+
+// MultiExporter 同时导出到多个导出器
+type MultiExporter []Exporter
+
+// ExportSpan 导出跨度
+func (m MultiExporter) ExportSpan(span *SpanData) {
+	for _, exp := range m {
+		exp.ExportSpan(span)
+	}
+}
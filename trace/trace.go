@@ -0,0 +1,247 @@
+package trace
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID 链路 ID（16 字节，与 OpenTelemetry / W3C Trace Context 兼容）
+type TraceID [16]byte
+
+// String 返回十六进制表示
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 是否为有效 ID（非全零）
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID 跨度 ID（8 字节）
+type SpanID [8]byte
+
+// String 返回十六进制表示
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid 是否为有效 ID（非全零）
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// StatusCode 跨度状态码
+type StatusCode string
+
+const (
+	StatusUnset StatusCode = "UNSET" // 未设置
+	StatusOK    StatusCode = "OK"    // 成功
+	StatusError StatusCode = "ERROR" // 失败
+)
+
+// SpanData 已结束跨度的只读数据，交给导出器使用
+type SpanData struct {
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Name          string
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]interface{}
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Duration 跨度耗时
+func (d *SpanData) Duration() time.Duration {
+	return d.EndTime.Sub(d.StartTime)
+}
+
+// Span 跨度
+// 所有方法对 nil 跨度都是安全的空操作，未启用追踪时 StartSpan 返回 nil
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// StartSpan 开始一个跨度
+// parent 为 nil 时创建新的链路（根跨度）；未启用追踪时返回 nil
+func StartSpan(parent *Span, name string) *Span {
+	if !Enabled() {
+		return nil
+	}
+
+	span := &Span{
+		data: SpanData{
+			SpanID:     newSpanID(),
+			Name:       name,
+			StartTime:  time.Now(),
+			Attributes: make(map[string]interface{}),
+			StatusCode: StatusUnset,
+		},
+	}
+
+	if parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newTraceID()
+	}
+
+	return span
+}
+
+// StartChild 开始一个子跨度，s 为 nil 时返回 nil
+// 与 StartSpan 不同，它不会在没有父跨度时创建新的链路
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return StartSpan(s, name)
+}
+
+// TraceID 获取链路 ID
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+	return s.data.TraceID
+}
+
+// SpanID 获取跨度 ID
+func (s *Span) SpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+	return s.data.SpanID
+}
+
+// SetAttribute 设置属性
+func (s *Span) SetAttribute(key string, value interface{}) *Span {
+	if s == nil {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+	return s
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, message string) *Span {
+	if s == nil {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+	return s
+}
+
+// SetError 记录错误，err 为 nil 时忽略
+func (s *Span) SetError(err error) *Span {
+	if s == nil || err == nil {
+		return s
+	}
+	return s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束跨度并交给导出器，重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+
+	// 复制一份，避免导出后继续被修改
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+
+	if exp := GetExporter(); exp != nil {
+		exp.ExportSpan(&data)
+	}
+}
+
+// TraceParent 返回 W3C traceparent 头部值，可用于向外部系统传播链路
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.data.TraceID, s.data.SpanID)
+}
+
+// ========== 全局配置 ==========
+
+var (
+	enabled    atomic.Bool
+	exporterMu sync.RWMutex
+	exporter   Exporter
+)
+
+// Enable 启用追踪并设置导出器
+func Enable(exp Exporter) {
+	exporterMu.Lock()
+	exporter = exp
+	exporterMu.Unlock()
+	enabled.Store(exp != nil)
+}
+
+// Disable 关闭追踪
+func Disable() {
+	enabled.Store(false)
+}
+
+// Enabled 是否启用追踪
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// GetExporter 获取当前导出器
+func GetExporter() Exporter {
+	exporterMu.RLock()
+	defer exporterMu.RUnlock()
+	return exporter
+}
+
+// newTraceID 生成链路 ID
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		hi, lo := rand.Uint64(), rand.Uint64()
+		for i := 0; i < 8; i++ {
+			id[i] = byte(hi >> (56 - 8*i))
+			id[8+i] = byte(lo >> (56 - 8*i))
+		}
+	}
+	return id
+}
+
+// newSpanID 生成跨度 ID
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		v := rand.Uint64()
+		for i := 0; i < 8; i++ {
+			id[i] = byte(v >> (56 - 8*i))
+		}
+	}
+	return id
+}
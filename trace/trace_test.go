@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestSpanLifecycle 测试跨度的父子关系与导出
+func TestSpanLifecycle(t *testing.T) {
+	exp := NewMemoryExporter(10)
+	Enable(exp)
+	defer Disable()
+
+	root := StartSpan(nil, "event")
+	child := root.StartChild("api.send_msg")
+	child.SetAttribute("action", "send_msg").SetError(errors.New("timeout"))
+	child.End()
+	child.End() // 重复结束不应重复导出
+	root.End()
+
+	spans := exp.Trace(root.TraceID().String())
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	c := spans[0]
+	if c.Name != "api.send_msg" || c.ParentSpanID != root.SpanID() {
+		t.Errorf("Unexpected child span: %+v", c)
+	}
+	if c.StatusCode != StatusError || c.StatusMessage != "timeout" {
+		t.Errorf("Expected error status, got %s %q", c.StatusCode, c.StatusMessage)
+	}
+
+	var buf bytes.Buffer
+	NewWriterExporter(&buf).ExportSpan(c)
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if out["traceId"] != root.TraceID().String() || out["parentSpanId"] != root.SpanID().String() {
+		t.Errorf("Unexpected JSON output: %s", buf.String())
+	}
+}
+
+// TestDisabledSpan 测试未启用追踪时跨度为 nil 且方法安全
+func TestDisabledSpan(t *testing.T) {
+	Disable()
+
+	span := StartSpan(nil, "event")
+	if span != nil {
+		t.Fatal("Expected nil span when tracing is disabled")
+	}
+
+	span.SetAttribute("k", "v").SetError(errors.New("x"))
+	span.StartChild("child").End()
+	span.End()
+
+	if span.TraceID().IsValid() {
+		t.Error("Expected invalid trace ID for nil span")
+	}
+}
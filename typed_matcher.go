@@ -0,0 +1,86 @@
+package xbot
+
+import (
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// 类型化的通知与请求匹配器
+// 处理函数可以增加第二个参数直接接收具体事件，无需对 ctx.Event 做类型断言：
+//
+//	engine.OnGroupIncrease().Handle(func(ctx *xbot.Context, e *event.GroupIncreaseNoticeEvent) {
+//	    ctx.SendGroupMessage(e.GroupID, message.At(e.UserID))
+//	})
+
+// eventMatcher 创建按事件类型匹配的匹配函数，match 为空时只检查类型
+func eventMatcher[E event.Event](match func(E) bool) func(*Context) bool {
+	return func(ctx *Context) bool {
+		evt, ok := ctx.Event.(E)
+		return ok && (match == nil || match(evt))
+	}
+}
+
+// addEventMatcher 创建并注册匹配器
+func (e *Engine) addEventMatcher(name string, matchFunc func(*Context) bool, filters []Filter) *Matcher {
+	matcher := newMatcher(name, matchFunc, filters...)
+	e.addMatcher(matcher)
+	return matcher
+}
+
+// OnGroupIncrease 群成员增加事件
+// 处理函数：func(ctx *Context, e *event.GroupIncreaseNoticeEvent)
+func (e *Engine) OnGroupIncrease(filters ...Filter) *Matcher {
+	return e.addEventMatcher("group_increase", eventMatcher[*event.GroupIncreaseNoticeEvent](nil), filters)
+}
+
+// OnGroupDecrease 群成员减少事件
+// 处理函数：func(ctx *Context, e *event.GroupDecreaseNoticeEvent)
+func (e *Engine) OnGroupDecrease(filters ...Filter) *Matcher {
+	return e.addEventMatcher("group_decrease", eventMatcher[*event.GroupDecreaseNoticeEvent](nil), filters)
+}
+
+// OnPoke 戳一戳事件，targetSelfOnly 为 true 时只匹配戳机器人自己
+// 处理函数：func(ctx *Context, e *event.NotifyNoticeEvent)
+func (e *Engine) OnPoke(targetSelfOnly bool, filters ...Filter) *Matcher {
+	return e.addEventMatcher("poke", eventMatcher(func(evt *event.NotifyNoticeEvent) bool {
+		return evt.IsPoke() && (!targetSelfOnly || evt.TargetID == evt.SelfID)
+	}), filters)
+}
+
+// OnGroupRecall 群消息撤回事件
+// 处理函数：func(ctx *Context, e *event.GroupRecallNoticeEvent)；
+// 需要原消息内容时使用 OnRecalledMessage
+func (e *Engine) OnGroupRecall(filters ...Filter) *Matcher {
+	return e.addEventMatcher("group_recall", eventMatcher[*event.GroupRecallNoticeEvent](nil), filters)
+}
+
+// OnFriendRequest 加好友请求事件
+// 处理函数：func(ctx *Context, e *event.FriendRequestEvent)
+func (e *Engine) OnFriendRequest(filters ...Filter) *Matcher {
+	return e.addEventMatcher("friend_request", eventMatcher[*event.FriendRequestEvent](nil), filters)
+}
+
+// OnGroupInvite 邀请机器人入群事件
+// 处理函数：func(ctx *Context, e *event.GroupRequestEvent)
+func (e *Engine) OnGroupInvite(filters ...Filter) *Matcher {
+	return e.addEventMatcher("group_invite", eventMatcher(func(evt *event.GroupRequestEvent) bool {
+		return evt.SubType == types.GroupRequestSubTypeInvite
+	}), filters)
+}
+
+// OnHonorChange 群荣誉变更事件（龙王、群聊之火、快乐源泉）
+// 处理函数：func(ctx *Context, e *event.NotifyNoticeEvent)
+func (e *Engine) OnHonorChange(filters ...Filter) *Matcher {
+	return e.addEventMatcher("honor_change", eventMatcher(func(evt *event.NotifyNoticeEvent) bool {
+		return evt.IsHonor()
+	}), filters)
+}
+
+// OnBotOffline 机器人离线事件
+// 包括框架根据心跳判断的离线，以及 NapCat 上报的 bot_offline 通知
+// 处理函数：func(ctx *Context, e *event.BotStatusEvent)
+func (e *Engine) OnBotOffline(filters ...Filter) *Matcher {
+	return e.addEventMatcher("bot_offline", eventMatcher(func(evt *event.BotStatusEvent) bool {
+		return !evt.Online
+	}), filters)
+}
@@ -0,0 +1,41 @@
+package xbot
+
+import (
+	"testing"
+
+	"github.com/xiaoyi510/xbot/event"
+	"github.com/xiaoyi510/xbot/types"
+)
+
+// TestTypedMatcher 测试类型化匹配器的匹配与事件参数传递
+func TestTypedMatcher(t *testing.T) {
+	e := &Engine{}
+	m := e.OnPoke(true)
+
+	var got *event.NotifyNoticeEvent
+	m.Handle(func(ctx *Context, evt *event.NotifyNoticeEvent) {
+		got = evt
+	})
+
+	bot := &Bot{Config: &Config{}}
+	base := event.BaseEvent{SelfID: 10000, PostType: types.PostTypeNotice}
+
+	other := NewContext(&event.NotifyNoticeEvent{BaseEvent: base, SubType: types.NotifySubTypePoke, TargetID: 1}, bot)
+	if m.Match(other) {
+		t.Error("Expected poke on other user not to match")
+	}
+
+	poke := &event.NotifyNoticeEvent{BaseEvent: base, SubType: types.NotifySubTypePoke, UserID: 2, TargetID: 10000}
+	ctx := NewContext(poke, bot)
+	if !m.Match(ctx) {
+		t.Fatal("Expected poke on self to match")
+	}
+	m.Execute(ctx)
+	if got != poke {
+		t.Errorf("Expected handler to receive the event, got %+v", got)
+	}
+
+	if e.OnGroupInvite().Match(NewContext(&event.GroupRequestEvent{BaseEvent: base, SubType: types.GroupRequestSubTypeAdd}, bot)) {
+		t.Error("Expected group add request not to match OnGroupInvite")
+	}
+}
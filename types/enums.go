@@ -8,6 +8,8 @@ const (
 	PostTypeNotice    PostType = "notice"     // 通知事件
 	PostTypeRequest   PostType = "request"    // 请求事件
 	PostTypeMetaEvent PostType = "meta_event" // 元事件
+
+	PostTypeMessageSent PostType = "message_sent" // 机器人自身发送的消息（go-cqhttp/NapCat 扩展）
 )
 
 // MessageType 消息类型
@@ -51,6 +53,13 @@ const (
 	NoticeTypeNotify        NoticeType = "notify"         // 群内提示
 	NoticeTypeGroupCard     NoticeType = "group_card"     // 群名片变更
 	NoticeTypeOfflineFile   NoticeType = "offline_file"   // 接收离线文件
+	NoticeTypeClientStatus  NoticeType = "client_status"  // 其他客户端在线状态变更（go-cqhttp 扩展）
+	NoticeTypeEssence       NoticeType = "essence"        // 精华消息变更（go-cqhttp 扩展）
+
+	NoticeTypeGroupMsgEmojiLike NoticeType = "group_msg_emoji_like" // 群消息表情回应（NapCat/LLOneBot 扩展）
+	NoticeTypeBotOffline        NoticeType = "bot_offline"          // 机器人账号下线（NapCat 扩展）
+
+	NoticeTypeRecalledMessage NoticeType = "recalled_message" // 撤回消息内容（框架内部事件）
 )
 
 // NotifySubType 提示类型子类型
@@ -61,6 +70,8 @@ const (
 	NotifySubTypeHonor NotifySubType = "honor"      // 群荣誉变更
 	NotifySubTypeLucky NotifySubType = "lucky_king" // 群红包运气王
 	NotifySubTypeTitle NotifySubType = "title"      // 群成员头衔变更
+
+	NotifySubTypeInputStatus NotifySubType = "input_status" // 对方正在输入（NapCat 扩展）
 )
 
 // RequestType 请求类型
@@ -83,8 +94,9 @@ const (
 type MetaEventType string
 
 const (
-	MetaEventTypeLifecycle MetaEventType = "lifecycle" // 生命周期
-	MetaEventTypeHeartbeat MetaEventType = "heartbeat" // 心跳
+	MetaEventTypeLifecycle MetaEventType = "lifecycle"  // 生命周期
+	MetaEventTypeHeartbeat MetaEventType = "heartbeat"  // 心跳
+	MetaEventTypeBotStatus MetaEventType = "bot_status" // 机器人在线状态变化（框架内部事件）
 )
 
 // LifecycleSubType 生命周期子类型
@@ -121,4 +133,9 @@ const (
 	HonorTypeTalkative HonorType = "talkative" // 龙王
 	HonorTypePerformer HonorType = "performer" // 群聊之火
 	HonorTypeEmotion   HonorType = "emotion"   // 快乐源泉
+
+	// 以下类型仅用于 get_group_honor_info
+	HonorTypeLegend       HonorType = "legend"        // 群聊炽焰
+	HonorTypeStrongNewbie HonorType = "strong_newbie" // 冒尖小春笋
+	HonorTypeAll          HonorType = "all"           // 所有类型
 )
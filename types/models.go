@@ -74,6 +74,7 @@ type UserInfo struct {
 	Nickname string `json:"nickname"` // 昵称
 	Sex      Sex    `json:"sex"`      // 性别
 	Age      int32  `json:"age"`      // 年龄
+	Level    int32  `json:"level"`    // QQ 等级（go-cqhttp、NapCat 扩展字段）
 }
 
 // FriendInfo 好友信息
@@ -91,6 +92,26 @@ type GroupHonorInfo struct {
 	Description string `json:"description"` // 荣誉描述
 }
 
+// CurrentTalkative 当前龙王
+type CurrentTalkative struct {
+	UserID   int64  `json:"user_id"`   // QQ 号
+	Nickname string `json:"nickname"`  // 昵称
+	Avatar   string `json:"avatar"`    // 头像 URL
+	DayCount int32  `json:"day_count"` // 持续天数
+}
+
+// GroupHonorData 群荣誉信息（get_group_honor_info 返回值）
+// 只有请求的荣誉类型对应的字段会被填充
+type GroupHonorData struct {
+	GroupID          int64             `json:"group_id"`                     // 群号
+	CurrentTalkative *CurrentTalkative `json:"current_talkative,omitempty"`  // 当前龙王
+	TalkativeList    []GroupHonorInfo  `json:"talkative_list,omitempty"`     // 历史龙王
+	PerformerList    []GroupHonorInfo  `json:"performer_list,omitempty"`     // 群聊之火
+	LegendList       []GroupHonorInfo  `json:"legend_list,omitempty"`        // 群聊炽焰
+	StrongNewbieList []GroupHonorInfo  `json:"strong_newbie_list,omitempty"` // 冒尖小春笋
+	EmotionList      []GroupHonorInfo  `json:"emotion_list,omitempty"`       // 快乐之源
+}
+
 // GroupFileSystemInfo 群文件系统信息
 type GroupFileSystemInfo struct {
 	FileCount  int32 `json:"file_count"`  // 文件总数
@@ -125,6 +146,17 @@ type GroupFolderInfo struct {
 	TotalFileCount int32  `json:"total_file_count"` // 子文件数量
 }
 
+// GroupFiles 群文件列表（文件和文件夹）
+type GroupFiles struct {
+	Files   []GroupFileInfo   `json:"files"`   // 文件列表
+	Folders []GroupFolderInfo `json:"folders"` // 文件夹列表
+}
+
+// GroupFileURL 群文件下载链接
+type GroupFileURL struct {
+	URL string `json:"url"` // 下载链接
+}
+
 // OfflineFileInfo 离线文件信息
 type OfflineFileInfo struct {
 	Name string `json:"name"` // 文件名
@@ -154,6 +186,20 @@ type ForwardMessage struct {
 	Messages []ForwardNode `json:"messages"` // 消息节点
 }
 
+// ForwardMessageData 合并转发内容（get_forward_msg 返回值）
+// OneBot v11 标准使用 message 字段，go-cqhttp 等实现使用 messages 字段
+type ForwardMessageData struct {
+	Message  interface{}          `json:"message,omitempty"`  // 消息内容（OneBot v11）
+	Messages []ForwardMessageItem `json:"messages,omitempty"` // 消息列表（go-cqhttp）
+}
+
+// ForwardMessageItem 合并转发中的单条消息
+type ForwardMessageItem struct {
+	Content interface{} `json:"content"` // 消息内容
+	Sender  Sender      `json:"sender"`  // 发送者
+	Time    int64       `json:"time"`    // 发送时间
+}
+
 // ForwardNode 合并转发节点
 type ForwardNode struct {
 	Type string                 `json:"type"` // 节点类型 node
@@ -162,6 +208,7 @@ type ForwardNode struct {
 
 // ImageInfo 图片信息
 type ImageInfo struct {
+	File     string `json:"file"`     // 图片本地路径
 	Size     int32  `json:"size"`     // 图片大小
 	FileName string `json:"filename"` // 图片文件名
 	URL      string `json:"url"`      // 图片链接
@@ -174,6 +221,11 @@ type RecordInfo struct {
 	OutFormat string `json:"out_format,omitempty"` // 转换后的格式
 }
 
+// CanSendInfo 能力检查结果（can_send_image / can_send_record 返回值）
+type CanSendInfo struct {
+	Yes bool `json:"yes"` // 是否可以发送
+}
+
 // LoginInfo 登录账号信息
 type LoginInfo struct {
 	UserID   int64  `json:"user_id"`  // QQ 号
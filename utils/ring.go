@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"sync"
+)
+
+// RingBuffer 并发安全的定长环形缓冲区，写满后覆盖最旧的元素
+type RingBuffer[T any] struct {
+	mu    sync.RWMutex
+	items []T
+	next  int
+	full  bool
+}
+
+// NewRingBuffer 创建环形缓冲区
+func NewRingBuffer[T any](size int) *RingBuffer[T] {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBuffer[T]{
+		items: make([]T, size),
+	}
+}
+
+// Push 追加元素
+func (r *RingBuffer[T]) Push(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[r.next] = item
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Items 返回所有元素，按写入顺序从旧到新排列
+func (r *RingBuffer[T]) Items() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.full {
+		return append([]T(nil), r.items[:r.next]...)
+	}
+
+	result := make([]T, 0, len(r.items))
+	result = append(result, r.items[r.next:]...)
+	return append(result, r.items[:r.next]...)
+}
+
+// Len 返回元素数量
+func (r *RingBuffer[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.full {
+		return len(r.items)
+	}
+	return r.next
+}
+
+// Cap 返回容量
+func (r *RingBuffer[T]) Cap() int {
+	return len(r.items)
+}
+
+// Clear 清空
+func (r *RingBuffer[T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = make([]T, len(r.items))
+	r.next = 0
+	r.full = false
+}